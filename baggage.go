@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// ErrInvalidBaggageValue is returned by WithBaggage for values which can not be propagated.
+var ErrInvalidBaggageValue = errors.New("invalid baggage value")
+
+// WithBaggage returns a copy of ctx with the baggage member. Baggage is propagated to downstream
+// services by Inject and the tracehttp and tracegrpc middlewares if the Propagator includes baggage.
+// The value must not contain whitespace, commas, semicolons, backslashes, quotes, '%' and '+'.
+func WithBaggage(ctx context.Context, key, value string) (context.Context, error) {
+	member, err := baggage.NewMember(key, value)
+	if err != nil {
+		return ctx, fmt.Errorf("baggage member '%s': %w", key, err)
+	}
+
+	// Encoded values are decoded by the member and can not be extracted by other services.
+	if member.Value() != value {
+		return ctx, fmt.Errorf("baggage member '%s': %w", key, ErrInvalidBaggageValue)
+	}
+
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx, fmt.Errorf("baggage member '%s': %w", key, err)
+	}
+
+	return baggage.ContextWithBaggage(ctx, bag), nil
+}
+
+// BaggageFromContext returns baggage members of the context.
+func BaggageFromContext(ctx context.Context) map[string]string {
+	members := baggage.FromContext(ctx).Members()
+	result := make(map[string]string, len(members))
+
+	for _, member := range members {
+		result[member.Key()] = member.Value()
+	}
+
+	return result
+}
+
+// BaggageValue returns value of the baggage member, empty if the member is not set.
+func BaggageValue(ctx context.Context, key string) string {
+	return baggage.FromContext(ctx).Member(key).Value()
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestWithBaggage(t *testing.T) {
+	ctx, err := WithBaggage(context.Background(), "tenant.id", "42")
+	require.NoError(t, err)
+
+	ctx, err = WithBaggage(ctx, "feature", "new-search")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"tenant.id": "42", "feature": "new-search"}, BaggageFromContext(ctx))
+	assert.Equal(t, "new-search", BaggageValue(ctx, "feature"))
+	assert.Empty(t, BaggageValue(ctx, "missing"))
+
+	_, err = WithBaggage(ctx, "invalid key", "value")
+	assert.Error(t, err)
+
+	_, err = WithBaggage(ctx, "feature", "new+search")
+	assert.ErrorIs(t, err, ErrInvalidBaggageValue)
+
+	_, err = WithBaggage(ctx, "feature", "new search")
+	assert.Error(t, err)
+
+	carrier := http.Header{}
+
+	InjectHeaders(ctx, carrier)
+
+	assert.Empty(t, carrier.Get("Baggage"), "baggage is not propagated by default")
+
+	propagator, err := NewPropagator("tracecontext", "baggage")
+	require.NoError(t, err)
+
+	defer setPropagator(Propagator())
+
+	setPropagator(propagator)
+
+	InjectHeaders(ctx, carrier)
+
+	extracted := Extract(context.Background(), propagation.HeaderCarrier(carrier))
+
+	assert.Equal(t, BaggageFromContext(ctx), BaggageFromContext(extracted))
+}
@@ -1,27 +1,200 @@
 package tracing
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/loghole/tracing/spanprocessor"
 )
 
 var ErrInvalidConfiguration = errors.New("invalid configuration")
 
+// Supported exporters.
+const (
+	ExporterJaeger   = "jaeger"
+	ExporterOTLPGRPC = "otlp+grpc"
+	ExporterOTLPHTTP = "otlp+http"
+	ExporterZipkin   = "zipkin"
+	ExporterFile     = "file"
+	ExporterStdout   = "stdout"
+)
+
+// Supported encodings of the OTLP/HTTP exporter.
+const (
+	EncodingProtobuf = "protobuf"
+	EncodingJSON     = "json"
+)
+
 // Configuration configures Tracer.
 type Configuration struct { //nolint:govet // not need.
 	ServiceName string
 	Addr        string
 	Disabled    bool
 
+	// Exporter selects exporter explicitly, by default it is detected from the Addr scheme.
+	// The Addr may omit the scheme then, e.g. "127.0.0.1:6831" is the jaeger agent address.
+	Exporter string
+
 	Sampler              tracesdk.Sampler
 	Attributes           []attribute.KeyValue
 	SpanProcessorOptions []tracesdk.BatchSpanProcessorOption
+
+	// TLSConfig enables TLS for the OTLP exporter, nil means insecure connection.
+	TLSConfig *tls.Config
+	// Headers are sent with each export request of the OTLP and zipkin exporters.
+	Headers map[string]string
+	// Compression sets compression of the OTLP exporter, supported values: gzip.
+	Compression string
+	// Timeout sets max waiting time for the exporter to send spans.
+	Timeout time.Duration
+	// Encoding sets payload encoding of the OTLP/HTTP exporter, protobuf by default.
+	Encoding string
+
+	// Spool keeps batches on disk while the exporter is unavailable.
+	Spool SpoolConfiguration
+
+	// TailSampling limits memory used by the sampler which buffers spans until the trace is finished.
+	TailSampling TailSamplingConfiguration
+
+	// Propagator is used by Inject, Extract, the SpanBuilder and the tracehttp and tracegrpc middlewares,
+	// it is also installed as the global text map propagator if set. See NewPropagator.
+	Propagator propagation.TextMapPropagator
+
+	// BaggageAttributes are keys of baggage members which are copied to attributes of every span.
+	BaggageAttributes []string
+
+	// Detectors populate the resource attributes, the Attributes take priority over detected ones.
+	// Nothing is detected by default, see DefaultDetectors.
+	Detectors []resource.Detector
+
+	// Exporters are additional exporters, spans sampled by the Sampler are sent to each of them.
+	Exporters []ExporterConfiguration
+}
+
+// ExporterConfiguration configures additional exporter.
+// Empty compression, timeout and encoding are inherited from the Configuration.
+type ExporterConfiguration struct { //nolint:govet // not need.
+	Addr     string
+	Exporter string
+
+	// TLSConfig is inherited only if the exporter host matches the Addr host of the Configuration.
+	TLSConfig *tls.Config
+	// Headers are not inherited, so credentials of one backend are never sent to another.
+	Headers     map[string]string
+	Compression string
+	Timeout     time.Duration
+	Encoding    string
+
+	SpanProcessorOptions []tracesdk.BatchSpanProcessorOption
+
+	// Spool is not inherited, each exporter needs own directory.
+	Spool SpoolConfiguration
+
+	// Sampler samples traces for this exporter only, after the common sampling decision.
+	// It decides once per trace by the local root span.
+	Sampler tracesdk.Sampler
+	// Filter drops spans for which returns false.
+	Filter func(span tracesdk.ReadOnlySpan) bool
+}
+
+// SpoolConfiguration configures disk buffering of the exporter. Batches which failed to export
+// are written to the Dir and replayed in order with exponential backoff when the exporter recovers.
+type SpoolConfiguration struct {
+	// Dir enables spooling, it must not be shared between exporters.
+	Dir string
+	// MaxSize limits total size of spooled batches in bytes, 100MiB by default.
+	MaxSize int64
+	// MaxAge drops spooled batches older than the value, zero means no limit.
+	MaxAge time.Duration
+	// MinBackoff and MaxBackoff limit delay between replay attempts, 1s and 1m by default.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// TailSamplingConfiguration configures the sampler which makes decision after the first span
+// of the trace in this process is ended. Zero values mean no limit.
+type TailSamplingConfiguration struct {
+	// MaxTraces limits number of buffered traces, the oldest trace is force-decided when it is reached.
+	MaxTraces int
+	// MaxSpansPerTrace limits number of buffered spans of one trace, spans over the limit are dropped.
+	MaxSpansPerTrace int
+	// MaxAge force-decides incomplete traces which were started earlier, e.g. with never ended root span.
+	MaxAge time.Duration
+	// Shards is the number of independently locked parts of the buffer, four per CPU by default.
+	// MaxTraces is shared by all shards.
+	Shards int
+
+	// ErrorPredicate detects spans with errors, traces with errors are kept regardless of the Sampler decision.
+	// The Error status and the "error" attribute are checked by default.
+	ErrorPredicate spanprocessor.ErrorPredicate
+	// Latency keeps traces with slow spans regardless of the Sampler decision.
+	Latency []spanprocessor.LatencyRule
+	// Policies are evaluated in order before the default checks, the first matched policy makes the decision.
+	Policies []spanprocessor.Policy
+	// RateLimit limits number of kept traces per key, it is disabled if PerSecond is zero.
+	// Decisions made by policies are not limited.
+	RateLimit spanprocessor.RateLimit
+	// PartialFlush decides long-lived traces before the root span ends, it is disabled by default.
+	PartialFlush spanprocessor.PartialFlush
+	// ForceKeep keeps traces which other services or ForceKeep calls ask to keep, and asks other
+	// services to keep traces with errors by the ErrorPredicate. Forced traces are not rate limited.
+	ForceKeep bool
+}
+
+func (c *TailSamplingConfiguration) options() []spanprocessor.Option {
+	var options []spanprocessor.Option
+
+	if c.ForceKeep {
+		options = append(options, spanprocessor.WithForceKeep())
+	}
+
+	if c.MaxTraces > 0 {
+		options = append(options, spanprocessor.WithMaxTraces(c.MaxTraces))
+	}
+
+	if c.MaxSpansPerTrace > 0 {
+		options = append(options, spanprocessor.WithMaxSpansPerTrace(c.MaxSpansPerTrace))
+	}
+
+	if c.MaxAge > 0 {
+		options = append(options, spanprocessor.WithMaxAge(c.MaxAge))
+	}
+
+	if c.Shards > 0 {
+		options = append(options, spanprocessor.WithShards(c.Shards))
+	}
+
+	if c.ErrorPredicate != nil {
+		options = append(options, spanprocessor.WithErrorPredicate(c.ErrorPredicate))
+	}
+
+	if len(c.Latency) > 0 {
+		options = append(options, spanprocessor.WithLatencyRules(c.Latency...))
+	}
+
+	if len(c.Policies) > 0 {
+		options = append(options, spanprocessor.WithPolicies(c.Policies...))
+	}
+
+	if c.PartialFlush.OnError || c.PartialFlush.MaxSpans > 0 || c.PartialFlush.MaxAge > 0 {
+		options = append(options, spanprocessor.WithPartialFlush(c.PartialFlush))
+	}
+
+	if c.RateLimit.PerSecond > 0 {
+		options = append(options, spanprocessor.WithRateLimit(c.RateLimit))
+	}
+
+	return options
 }
 
 // DefaultConfiguration returns base configuration with default params.
@@ -45,21 +218,86 @@ func (c *Configuration) validate() error {
 		return fmt.Errorf("%w: sampler cannot be empty", ErrInvalidConfiguration)
 	}
 
+	for idx, exporter := range c.Exporters {
+		if exporter.Addr == "" && exporter.Exporter == "" {
+			return fmt.Errorf("%w: empty addr of exporter %d", ErrInvalidConfiguration, idx)
+		}
+	}
+
 	return nil
 }
 
-func (c *Configuration) endpoint() (jaeger.EndpointOption, error) {
-	u, err := url.Parse(c.Addr)
+// hasPrimaryExporter reports whether the exporter is configured by the Addr field.
+func (c *Configuration) hasPrimaryExporter() bool {
+	return c.Addr != "" || c.Exporter != "" || len(c.Exporters) == 0
+}
+
+// withExporter returns copy of the configuration with settings of the additional exporter.
+func (c *Configuration) withExporter(exporter *ExporterConfiguration) *Configuration {
+	result := *c
+
+	result.Addr = exporter.Addr
+	result.Exporter = exporter.Exporter
+	result.SpanProcessorOptions = exporter.SpanProcessorOptions
+	result.Spool = exporter.Spool
+	result.Headers = exporter.Headers
+	result.Exporters = nil
+
+	if exporter.TLSConfig != nil || !c.sameHost(&result) {
+		result.TLSConfig = exporter.TLSConfig
+	}
+
+	if exporter.Compression != "" {
+		result.Compression = exporter.Compression
+	}
+
+	if exporter.Timeout != 0 {
+		result.Timeout = exporter.Timeout
+	}
+
+	if exporter.Encoding != "" {
+		result.Encoding = exporter.Encoding
+	}
+
+	return &result
+}
+
+// sameHost reports whether both configurations send spans to the same host.
+func (c *Configuration) sameHost(other *Configuration) bool {
+	u, err := c.addr()
 	if err != nil {
-		return nil, fmt.Errorf("parse addr: %w", err)
+		return false
 	}
 
-	switch strings.ToLower(u.Scheme) {
+	otherURL, err := other.addr()
+	if err != nil {
+		return false
+	}
+
+	return u.Hostname() != "" && strings.EqualFold(u.Hostname(), otherURL.Hostname())
+}
+
+// endpoint returns the jaeger collector endpoint for http, https and jaeger addrs and the agent endpoint
+// for udp addrs. The jaeger addr is the collector endpoint over http. The addr without scheme is the agent
+// endpoint when the Exporter is set.
+func (c *Configuration) endpoint(u *url.URL) (jaeger.EndpointOption, error) {
+	scheme := strings.ToLower(u.Scheme)
+
+	if scheme == "" && c.Exporter != "" && u.Host != "" {
+		scheme = "udp"
+	}
+
+	switch scheme {
 	case "http", "https":
 		return jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(u.String())), nil
+	case ExporterJaeger:
+		collector := *u
+		collector.Scheme = "http"
+
+		return jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(collector.String())), nil
 	case "udp":
 		return jaeger.WithAgentEndpoint(jaeger.WithAgentHost(u.Hostname()), jaeger.WithAgentPort(u.Port())), nil
 	default:
-		return nil, fmt.Errorf("%w: unknown addr scheme, supported [http, https, udp]", ErrInvalidConfiguration)
+		return nil, fmt.Errorf("%w: unknown addr scheme, supported [http, https, jaeger, udp]", ErrInvalidConfiguration)
 	}
 }
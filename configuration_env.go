@@ -0,0 +1,342 @@
+package tracing
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+// Environment variables supported by ConfigurationFromEnv.
+const (
+	EnvSDKDisabled        = "OTEL_SDK_DISABLED"
+	EnvServiceName        = "OTEL_SERVICE_NAME"
+	EnvResourceAttributes = "OTEL_RESOURCE_ATTRIBUTES"
+	EnvPropagators        = "OTEL_PROPAGATORS"
+	EnvTracesExporter     = "OTEL_TRACES_EXPORTER"
+	EnvTracesSampler      = "OTEL_TRACES_SAMPLER"
+	EnvTracesSamplerArg   = "OTEL_TRACES_SAMPLER_ARG"
+
+	EnvExporterOTLPEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvExporterOTLPTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	EnvExporterOTLPProtocol       = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	EnvExporterOTLPTracesProtocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+	EnvExporterOTLPHeaders        = "OTEL_EXPORTER_OTLP_HEADERS"
+	EnvExporterOTLPCompression    = "OTEL_EXPORTER_OTLP_COMPRESSION"
+	EnvExporterOTLPTimeout        = "OTEL_EXPORTER_OTLP_TIMEOUT"
+
+	EnvBSPScheduleDelay      = "OTEL_BSP_SCHEDULE_DELAY"
+	EnvBSPExportTimeout      = "OTEL_BSP_EXPORT_TIMEOUT"
+	EnvBSPMaxQueueSize       = "OTEL_BSP_MAX_QUEUE_SIZE"
+	EnvBSPMaxExportBatchSize = "OTEL_BSP_MAX_EXPORT_BATCH_SIZE"
+)
+
+// Supported OTLP protocols, they match OTEL_EXPORTER_OTLP_PROTOCOL values.
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+	ProtocolHTTPJSON     = "http/json"
+)
+
+// Default OTLP endpoints by the protocol, they are used when the endpoint is not set.
+const (
+	_defaultOTLPGRPCEndpoint = "http://localhost:4317"
+	_defaultOTLPHTTPEndpoint = "http://localhost:4318"
+)
+
+// ConfigurationFromEnv returns configuration from the standard OTEL_* environment variables.
+//
+// Tracer is disabled when OTEL_SDK_DISABLED is true or OTEL_TRACES_EXPORTER is none. OTLP endpoint
+// is localhost:4317 for the grpc protocol and localhost:4318 for the http protocols by default.
+// Sampler is always_on by default like in DefaultConfiguration.
+// The OTEL_BSP_* variables are mapped to SpanProcessorOptions.
+func ConfigurationFromEnv() (*Configuration, error) {
+	var (
+		configuration = DefaultConfiguration("", "")
+		err           error
+	)
+
+	if configuration.Attributes, err = parseResourceAttributes(os.Getenv(EnvResourceAttributes)); err != nil {
+		return nil, envError(EnvResourceAttributes, err)
+	}
+
+	configuration.ServiceName = os.Getenv(EnvServiceName)
+
+	if configuration.ServiceName == "" {
+		configuration.ServiceName = serviceNameFromAttributes(configuration.Attributes)
+	}
+
+	if configuration.ServiceName == "" {
+		configuration.ServiceName = "unknown_service:" + filepath.Base(os.Args[0])
+	}
+
+	if name := os.Getenv(EnvTracesSampler); name != "" {
+		if configuration.Sampler, err = ParseSampler(name, os.Getenv(EnvTracesSamplerArg)); err != nil {
+			if errors.Is(err, errInvalidSamplerArg) {
+				return nil, envError(EnvTracesSamplerArg, err)
+			}
+
+			return nil, envError(EnvTracesSampler, err)
+		}
+	}
+
+	if value := os.Getenv(EnvPropagators); value != "" {
+		if configuration.Propagator, err = parsePropagators(value); err != nil {
+			return nil, envError(EnvPropagators, err)
+		}
+	}
+
+	if configuration.SpanProcessorOptions, err = batchOptionsFromEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := configuration.exporterFromEnv(); err != nil {
+		return nil, err
+	}
+
+	disabled, err := envBool(EnvSDKDisabled)
+	if err != nil {
+		return nil, envError(EnvSDKDisabled, err)
+	}
+
+	// Addr is empty only if the exporter is none.
+	configuration.Disabled = disabled || configuration.Addr == ""
+
+	return configuration, nil
+}
+
+func (c *Configuration) exporterFromEnv() error {
+	switch exporter := strings.ToLower(os.Getenv(EnvTracesExporter)); exporter {
+	case "", "otlp":
+	case "none":
+		return nil
+	default:
+		return envError(EnvTracesExporter, fmt.Errorf("%w: unsupported exporter '%s', supported [otlp, none]",
+			ErrInvalidConfiguration, exporter))
+	}
+
+	protocolKey := EnvExporterOTLPTracesProtocol
+
+	protocol := os.Getenv(protocolKey)
+	if protocol == "" {
+		protocolKey = EnvExporterOTLPProtocol
+		protocol = os.Getenv(protocolKey)
+	}
+
+	endpointKey := EnvExporterOTLPTracesEndpoint
+
+	endpoint, signal := os.Getenv(endpointKey), true
+	if endpoint == "" {
+		endpointKey = EnvExporterOTLPEndpoint
+		endpoint, signal = os.Getenv(endpointKey), false
+	}
+
+	if endpoint == "" {
+		endpoint = _defaultOTLPGRPCEndpoint
+
+		if protocol := strings.ToLower(protocol); protocol == ProtocolHTTPProtobuf || protocol == ProtocolHTTPJSON {
+			endpoint = _defaultOTLPHTTPEndpoint
+		}
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return envError(endpointKey, fmt.Errorf("%w: invalid endpoint '%s'", ErrInvalidConfiguration, endpoint))
+	}
+
+	if strings.EqualFold(u.Scheme, "https") {
+		c.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	switch strings.ToLower(protocol) {
+	case "", ProtocolGRPC:
+		c.Exporter = ExporterOTLPGRPC
+		u.Path = ""
+	case ProtocolHTTPProtobuf, ProtocolHTTPJSON:
+		c.Exporter = ExporterOTLPHTTP
+		c.Encoding = EncodingProtobuf
+
+		if strings.EqualFold(protocol, ProtocolHTTPJSON) {
+			c.Encoding = EncodingJSON
+		}
+
+		// Signal specific endpoint is used as is, base endpoint is extended by the traces path.
+		if !signal {
+			u.Path = strings.TrimSuffix(u.Path, "/") + _defaultOTLPHTTPPath
+		}
+	default:
+		return envError(protocolKey, fmt.Errorf("%w: unsupported protocol '%s', supported [%s, %s, %s]",
+			ErrInvalidConfiguration, protocol, ProtocolGRPC, ProtocolHTTPProtobuf, ProtocolHTTPJSON))
+	}
+
+	c.Addr = u.String()
+
+	if c.Headers, err = parseKeyValues(os.Getenv(EnvExporterOTLPHeaders)); err != nil {
+		return envError(EnvExporterOTLPHeaders, err)
+	}
+
+	c.Compression = os.Getenv(EnvExporterOTLPCompression)
+
+	if _, err := c.gzip(); err != nil {
+		return envError(EnvExporterOTLPCompression, err)
+	}
+
+	if c.Timeout, err = envMilliseconds(EnvExporterOTLPTimeout); err != nil {
+		return envError(EnvExporterOTLPTimeout, err)
+	}
+
+	return nil
+}
+
+func batchOptionsFromEnv() ([]tracesdk.BatchSpanProcessorOption, error) {
+	var options []tracesdk.BatchSpanProcessorOption
+
+	if delay, err := envMilliseconds(EnvBSPScheduleDelay); err != nil {
+		return nil, envError(EnvBSPScheduleDelay, err)
+	} else if delay > 0 {
+		options = append(options, tracesdk.WithBatchTimeout(delay))
+	}
+
+	if timeout, err := envMilliseconds(EnvBSPExportTimeout); err != nil {
+		return nil, envError(EnvBSPExportTimeout, err)
+	} else if timeout > 0 {
+		options = append(options, tracesdk.WithExportTimeout(timeout))
+	}
+
+	if size, err := envPositiveInt(EnvBSPMaxQueueSize); err != nil {
+		return nil, envError(EnvBSPMaxQueueSize, err)
+	} else if size > 0 {
+		options = append(options, tracesdk.WithMaxQueueSize(size))
+	}
+
+	if size, err := envPositiveInt(EnvBSPMaxExportBatchSize); err != nil {
+		return nil, envError(EnvBSPMaxExportBatchSize, err)
+	} else if size > 0 {
+		options = append(options, tracesdk.WithMaxExportBatchSize(size))
+	}
+
+	return options, nil
+}
+
+func parseResourceAttributes(value string) ([]attribute.KeyValue, error) {
+	pairs, err := parseKeyValues(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	// Keep order of the attributes for the predictable result.
+	attributes := make([]attribute.KeyValue, 0, len(pairs))
+
+	for _, item := range strings.Split(value, ",") {
+		key, _, _ := strings.Cut(item, "=")
+
+		val, ok := pairs[strings.TrimSpace(key)]
+		if !ok {
+			continue
+		}
+
+		delete(pairs, strings.TrimSpace(key))
+
+		attributes = append(attributes, attribute.String(strings.TrimSpace(key), val))
+	}
+
+	return attributes, nil
+}
+
+func serviceNameFromAttributes(attributes []attribute.KeyValue) string {
+	for _, attr := range attributes {
+		if attr.Key == semconv.ServiceNameKey {
+			return attr.Value.AsString()
+		}
+	}
+
+	return ""
+}
+
+// parseKeyValues parses comma separated list of url encoded key=value pairs.
+func parseKeyValues(value string) (map[string]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil //nolint:nilnil // empty list.
+	}
+
+	result := make(map[string]string)
+
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+
+		key, val, ok := strings.Cut(item, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, fmt.Errorf("%w: invalid key-value pair '%s'", ErrInvalidConfiguration, item)
+		}
+
+		decoded, err := url.PathUnescape(strings.TrimSpace(val))
+		if err != nil {
+			return nil, fmt.Errorf("%w: decode value of '%s': %w", ErrInvalidConfiguration, key, err)
+		}
+
+		result[key] = decoded
+	}
+
+	return result, nil
+}
+
+func envBool(key string) (bool, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return false, nil
+	}
+
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: parse bool '%s': %w", ErrInvalidConfiguration, value, err)
+	}
+
+	return result, nil
+}
+
+func envMilliseconds(key string) (time.Duration, error) {
+	value, err := envPositiveInt(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(value) * time.Millisecond, nil
+}
+
+func envPositiveInt(key string) (int, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, nil
+	}
+
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: parse int '%s': %w", ErrInvalidConfiguration, value, err)
+	}
+
+	if result < 0 {
+		return 0, fmt.Errorf("%w: value must not be negative, got %d", ErrInvalidConfiguration, result)
+	}
+
+	return result, nil
+}
+
+// envError adds name of the environment variable to the error.
+func envError(key string, err error) error {
+	return fmt.Errorf("%w (%s)", err, key)
+}
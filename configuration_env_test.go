@@ -0,0 +1,208 @@
+package tracing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestConfigurationFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(t *testing.T, c *Configuration)
+		wantErr string
+	}{
+		{
+			name: "empty",
+			env:  map[string]string{},
+			check: func(t *testing.T, c *Configuration) {
+				assert.False(t, c.Disabled)
+				assert.Contains(t, c.ServiceName, "unknown_service:")
+				assert.Equal(t, tracesdk.AlwaysSample(), c.Sampler)
+				assert.Equal(t, ExporterOTLPGRPC, c.Exporter)
+				assert.Equal(t, "http://localhost:4317", c.Addr)
+			},
+		},
+		{
+			name: "http default endpoint",
+			env:  map[string]string{EnvExporterOTLPProtocol: "http/protobuf"},
+			check: func(t *testing.T, c *Configuration) {
+				assert.False(t, c.Disabled)
+				assert.Equal(t, ExporterOTLPHTTP, c.Exporter)
+				assert.Equal(t, "http://localhost:4318/v1/traces", c.Addr)
+			},
+		},
+		{
+			name: "grpc",
+			env: map[string]string{
+				EnvServiceName:             "test",
+				EnvExporterOTLPEndpoint:    "https://collector:4317",
+				EnvExporterOTLPHeaders:     "authorization=Bearer%20token,x-tenant=1",
+				EnvExporterOTLPCompression: "gzip",
+				EnvExporterOTLPTimeout:     "5000",
+			},
+			check: func(t *testing.T, c *Configuration) {
+				assert.False(t, c.Disabled)
+				assert.Equal(t, "test", c.ServiceName)
+				assert.Equal(t, ExporterOTLPGRPC, c.Exporter)
+				assert.Equal(t, "https://collector:4317", c.Addr)
+				assert.NotNil(t, c.TLSConfig)
+				assert.Equal(t, map[string]string{"authorization": "Bearer token", "x-tenant": "1"}, c.Headers)
+				assert.Equal(t, "gzip", c.Compression)
+				assert.Equal(t, 5*time.Second, c.Timeout)
+			},
+		},
+		{
+			name: "http json",
+			env: map[string]string{
+				EnvExporterOTLPEndpoint: "http://collector:4318/otlp/",
+				EnvExporterOTLPProtocol: "http/json",
+				EnvResourceAttributes:   "service.name=from-resource,deployment.environment=prod",
+			},
+			check: func(t *testing.T, c *Configuration) {
+				assert.Equal(t, "from-resource", c.ServiceName)
+				assert.Equal(t, ExporterOTLPHTTP, c.Exporter)
+				assert.Equal(t, EncodingJSON, c.Encoding)
+				assert.Equal(t, "http://collector:4318/otlp/v1/traces", c.Addr)
+				assert.Nil(t, c.TLSConfig)
+				assert.Equal(t, []attribute.KeyValue{
+					attribute.String("service.name", "from-resource"),
+					attribute.String("deployment.environment", "prod"),
+				}, c.Attributes)
+			},
+		},
+		{
+			name: "traces endpoint",
+			env: map[string]string{
+				EnvExporterOTLPEndpoint:       "http://collector:4318",
+				EnvExporterOTLPTracesEndpoint: "http://traces:4318/custom",
+				EnvExporterOTLPTracesProtocol: "http/protobuf",
+			},
+			check: func(t *testing.T, c *Configuration) {
+				assert.Equal(t, "http://traces:4318/custom", c.Addr)
+				assert.Equal(t, EncodingProtobuf, c.Encoding)
+			},
+		},
+		{
+			name: "sampler, propagators and batch",
+			env: map[string]string{
+				EnvExporterOTLPEndpoint:  "http://collector:4317",
+				EnvTracesSampler:         "parentbased_traceidratio",
+				EnvTracesSamplerArg:      "0.25",
+				EnvPropagators:           "tracecontext,baggage",
+				EnvBSPScheduleDelay:      "1000",
+				EnvBSPExportTimeout:      "2000",
+				EnvBSPMaxQueueSize:       "100",
+				EnvBSPMaxExportBatchSize: "10",
+			},
+			check: func(t *testing.T, c *Configuration) {
+				assert.Equal(t, tracesdk.ParentBased(tracesdk.TraceIDRatioBased(0.25)).Description(), c.Sampler.Description())
+				assert.ElementsMatch(t, propagation.NewCompositeTextMapPropagator(
+					propagation.TraceContext{}, propagation.Baggage{},
+				).Fields(), c.Propagator.Fields())
+				assert.Len(t, c.SpanProcessorOptions, 4)
+			},
+		},
+		{
+			name: "sdk disabled",
+			env: map[string]string{
+				EnvExporterOTLPEndpoint: "http://collector:4317",
+				EnvSDKDisabled:          "true",
+			},
+			check: func(t *testing.T, c *Configuration) {
+				assert.True(t, c.Disabled)
+			},
+		},
+		{
+			name: "exporter none",
+			env: map[string]string{
+				EnvExporterOTLPEndpoint: "http://collector:4317",
+				EnvTracesExporter:       "none",
+			},
+			check: func(t *testing.T, c *Configuration) {
+				assert.True(t, c.Disabled)
+			},
+		},
+		{
+			name:    "invalid sampler",
+			env:     map[string]string{EnvTracesSampler: "sometimes"},
+			wantErr: "invalid configuration: unknown sampler 'sometimes' (OTEL_TRACES_SAMPLER)",
+		},
+		{
+			name:    "invalid sampler arg",
+			env:     map[string]string{EnvTracesSampler: "traceidratio", EnvTracesSamplerArg: "2"},
+			wantErr: "invalid configuration: sampler arg must be in range [0, 1], got 2 (OTEL_TRACES_SAMPLER_ARG)",
+		},
+		{
+			name:    "invalid sampler arg syntax",
+			env:     map[string]string{EnvTracesSampler: "parentbased_traceidratio", EnvTracesSamplerArg: "x"},
+			wantErr: "invalid configuration: parse sampler arg 'x': strconv.ParseFloat: parsing \"x\": invalid syntax (OTEL_TRACES_SAMPLER_ARG)",
+		},
+		{
+			name: "sampler arg ignored",
+			env:  map[string]string{EnvTracesSampler: "parentbased_always_off", EnvTracesSamplerArg: "x"},
+			check: func(t *testing.T, c *Configuration) {
+				assert.Equal(t, tracesdk.ParentBased(tracesdk.NeverSample()).Description(), c.Sampler.Description())
+			},
+		},
+		{
+			name:    "invalid propagator",
+			env:     map[string]string{EnvPropagators: "xray"},
+			wantErr: "invalid configuration: unsupported propagator 'xray', supported [tracecontext, baggage, b3, b3multi, jaeger, none] (OTEL_PROPAGATORS)",
+		},
+		{
+			name:    "invalid disabled",
+			env:     map[string]string{EnvSDKDisabled: "maybe"},
+			wantErr: "invalid configuration: parse bool 'maybe': strconv.ParseBool: parsing \"maybe\": invalid syntax (OTEL_SDK_DISABLED)",
+		},
+		{
+			name:    "invalid queue size",
+			env:     map[string]string{EnvBSPMaxQueueSize: "-1"},
+			wantErr: "invalid configuration: value must not be negative, got -1 (OTEL_BSP_MAX_QUEUE_SIZE)",
+		},
+		{
+			name:    "invalid protocol",
+			env:     map[string]string{EnvExporterOTLPEndpoint: "http://collector:4317", EnvExporterOTLPProtocol: "thrift"},
+			wantErr: "invalid configuration: unsupported protocol 'thrift', supported [grpc, http/protobuf, http/json] (OTEL_EXPORTER_OTLP_PROTOCOL)",
+		},
+		{
+			name:    "invalid headers",
+			env:     map[string]string{EnvExporterOTLPEndpoint: "http://collector:4317", EnvExporterOTLPHeaders: "token"},
+			wantErr: "invalid configuration: invalid key-value pair 'token' (OTEL_EXPORTER_OTLP_HEADERS)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range envKeys() {
+				t.Setenv(key, tt.env[key])
+			}
+
+			got, err := ConfigurationFromEnv()
+			if tt.wantErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidConfiguration)
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			tt.check(t, got)
+		})
+	}
+}
+
+func envKeys() []string {
+	return []string{
+		EnvSDKDisabled, EnvServiceName, EnvResourceAttributes, EnvPropagators, EnvTracesExporter,
+		EnvTracesSampler, EnvTracesSamplerArg, EnvExporterOTLPEndpoint, EnvExporterOTLPTracesEndpoint,
+		EnvExporterOTLPProtocol, EnvExporterOTLPTracesProtocol, EnvExporterOTLPHeaders,
+		EnvExporterOTLPCompression, EnvExporterOTLPTimeout, EnvBSPScheduleDelay, EnvBSPExportTimeout,
+		EnvBSPMaxQueueSize, EnvBSPMaxExportBatchSize,
+	}
+}
@@ -0,0 +1,790 @@
+package tracing
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	grpccodes "google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v3"
+
+	"github.com/loghole/tracing/spanprocessor"
+)
+
+// Error detectors of the ErrorsSpec.
+const (
+	ErrorDetectorStatus    = "status"
+	ErrorDetectorAttribute = "attribute"
+	ErrorDetectorException = "exception"
+	ErrorDetectorHTTP      = "http"
+	ErrorDetectorGRPC      = "grpc"
+)
+
+// _defaultGRPCErrorCodes are codes of server side failures.
+var _defaultGRPCErrorCodes = []int64{
+	int64(grpccodes.Unknown),
+	int64(grpccodes.DeadlineExceeded),
+	int64(grpccodes.Unimplemented),
+	int64(grpccodes.Internal),
+	int64(grpccodes.Unavailable),
+	int64(grpccodes.DataLoss),
+}
+
+// ConfigurationSpec is the serialisable form of the Configuration, it can be
+// loaded from YAML or JSON and compiled into the Configuration.
+//
+// Example:
+//
+//	service_name: example
+//	sampler: parentbased_traceidratio:0.1
+//	propagators: [tracecontext, baggage]
+//	baggage_attributes: [tenant.id]
+//	attributes:
+//	  deployment.environment: prod
+//	detectors: [host, process, build, container, k8s]
+//	tail_sampling:
+//	  max_traces: 10000
+//	  max_age: 1m
+//	  errors:
+//	    detect: [status, exception, http, grpc]
+//	    grpc_codes: [internal, unavailable]
+//	  latency:
+//	    - threshold: 2s
+//	    - route: /api/v1/search
+//	      threshold: 500ms
+//	  policies:
+//	    - name: drop-health
+//	      decision: drop
+//	      condition:
+//	        attribute: {key: http.route, regex: ^/health}
+//	    - name: keep-payments
+//	      condition:
+//	        and:
+//	          - service_name: payments
+//	          - rate_limit: 10
+//	  rate_limit:
+//	    per_second: 5
+//	    min_errors: 1
+//	    min_slow: 1
+//	    interval: 10s
+//	  partial_flush:
+//	    on_error: true
+//	    max_spans: 1000
+//	    max_age: 5m
+//	exporters:
+//	  - addr: otlp+grpc://127.0.0.1:4317
+//	    compression: gzip
+//	    timeout: 5s
+//	    batch:
+//	      max_queue_size: 4096
+//	      schedule_delay: 1s
+type ConfigurationSpec struct {
+	ServiceName string            `json:"service_name" yaml:"service_name"`
+	Disabled    bool              `json:"disabled" yaml:"disabled"`
+	Sampler     string            `json:"sampler" yaml:"sampler"`
+	Propagators []string          `json:"propagators" yaml:"propagators"`
+	Attributes  map[string]string `json:"attributes" yaml:"attributes"`
+	Detectors   []string          `json:"detectors" yaml:"detectors"`
+	Exporters   []ExporterSpec    `json:"exporters" yaml:"exporters"`
+
+	BaggageAttributes []string `json:"baggage_attributes" yaml:"baggage_attributes"`
+
+	TailSampling TailSamplingSpec `json:"tail_sampling" yaml:"tail_sampling"`
+}
+
+// TailSamplingSpec is the serialisable form of the TailSamplingConfiguration.
+type TailSamplingSpec struct {
+	MaxTraces        int      `json:"max_traces" yaml:"max_traces"`
+	MaxSpansPerTrace int      `json:"max_spans_per_trace" yaml:"max_spans_per_trace"`
+	MaxAge           Duration `json:"max_age" yaml:"max_age"`
+	Shards           int      `json:"shards" yaml:"shards"`
+
+	Errors ErrorsSpec `json:"errors" yaml:"errors"`
+
+	Latency   []LatencyRuleSpec `json:"latency" yaml:"latency"`
+	Policies  []PolicySpec      `json:"policies" yaml:"policies"`
+	RateLimit RateLimitSpec     `json:"rate_limit" yaml:"rate_limit"`
+
+	PartialFlush PartialFlushSpec `json:"partial_flush" yaml:"partial_flush"`
+	ForceKeep    bool             `json:"force_keep" yaml:"force_keep"`
+}
+
+// PartialFlushSpec is the serialisable form of the spanprocessor.PartialFlush.
+type PartialFlushSpec struct {
+	OnError  bool     `json:"on_error" yaml:"on_error"`
+	MaxSpans int      `json:"max_spans" yaml:"max_spans"`
+	MaxAge   Duration `json:"max_age" yaml:"max_age"`
+}
+
+// ErrorsSpec selects built-in error predicates by names: status, attribute, exception, http and grpc.
+// GRPCCodes are codes for the grpc predicate like "internal" or "unavailable", server side failures by default.
+type ErrorsSpec struct {
+	Detect    []string `json:"detect" yaml:"detect"`
+	GRPCCodes []string `json:"grpc_codes" yaml:"grpc_codes"`
+}
+
+// RateLimitSpec is the serialisable form of the spanprocessor.RateLimit, traces are keyed by the root span name.
+type RateLimitSpec struct {
+	PerSecond float64  `json:"per_second" yaml:"per_second"`
+	MinErrors int      `json:"min_errors" yaml:"min_errors"`
+	MinSlow   int      `json:"min_slow" yaml:"min_slow"`
+	Interval  Duration `json:"interval" yaml:"interval"`
+	MaxKeys   int      `json:"max_keys" yaml:"max_keys"`
+}
+
+// LatencyRuleSpec is the serialisable form of the spanprocessor.LatencyRule.
+type LatencyRuleSpec struct {
+	Name      string   `json:"name" yaml:"name"`
+	Route     string   `json:"route" yaml:"route"`
+	Threshold Duration `json:"threshold" yaml:"threshold"`
+}
+
+// PolicySpec is the serialisable form of the spanprocessor.Policy, Decision is "keep" (default) or "drop".
+type PolicySpec struct {
+	Name      string        `json:"name" yaml:"name"`
+	Decision  string        `json:"decision" yaml:"decision"`
+	Condition ConditionSpec `json:"condition" yaml:"condition"`
+}
+
+// ConditionSpec is the serialisable form of the spanprocessor.Condition.
+// All set fields must match, empty condition matches all traces.
+type ConditionSpec struct {
+	And         []ConditionSpec         `json:"and" yaml:"and"`
+	Or          []ConditionSpec         `json:"or" yaml:"or"`
+	Not         *ConditionSpec          `json:"not" yaml:"not"`
+	Attribute   *AttributeConditionSpec `json:"attribute" yaml:"attribute"`
+	StatusCode  string                  `json:"status_code" yaml:"status_code"`
+	Latency     Duration                `json:"latency" yaml:"latency"`
+	SpanCount   *SpanCountSpec          `json:"span_count" yaml:"span_count"`
+	SpanKind    string                  `json:"span_kind" yaml:"span_kind"`
+	ServiceName string                  `json:"service_name" yaml:"service_name"`
+	Probability *float64                `json:"probability" yaml:"probability"`
+	RateLimit   float64                 `json:"rate_limit" yaml:"rate_limit"`
+}
+
+// AttributeConditionSpec matches the attribute by one of Equals, Regex or the [Min, Max] range.
+type AttributeConditionSpec struct {
+	Key    string   `json:"key" yaml:"key"`
+	Equals *string  `json:"equals" yaml:"equals"`
+	Regex  string   `json:"regex" yaml:"regex"`
+	Min    *float64 `json:"min" yaml:"min"`
+	Max    *float64 `json:"max" yaml:"max"`
+}
+
+// SpanCountSpec matches number of spans in range [Min, Max], zero Max means no upper limit.
+type SpanCountSpec struct {
+	Min int `json:"min" yaml:"min"`
+	Max int `json:"max" yaml:"max"`
+}
+
+// ExporterSpec is the serialisable form of the ExporterConfiguration.
+type ExporterSpec struct {
+	Addr        string            `json:"addr" yaml:"addr"`
+	Exporter    string            `json:"exporter" yaml:"exporter"`
+	Headers     map[string]string `json:"headers" yaml:"headers"`
+	Compression string            `json:"compression" yaml:"compression"`
+	Timeout     Duration          `json:"timeout" yaml:"timeout"`
+	Encoding    string            `json:"encoding" yaml:"encoding"`
+	TLS         *TLSSpec          `json:"tls" yaml:"tls"`
+	Batch       BatchSpec         `json:"batch" yaml:"batch"`
+	Spool       SpoolSpec         `json:"spool" yaml:"spool"`
+	// Sampler samples traces for this exporter only, it has the same format as ConfigurationSpec.Sampler.
+	Sampler string `json:"sampler" yaml:"sampler"`
+}
+
+// TLSSpec configures TLS connection of the exporter.
+type TLSSpec struct {
+	ServerName         string `json:"server_name" yaml:"server_name"`
+	CAFile             string `json:"ca_file" yaml:"ca_file"`
+	CertFile           string `json:"cert_file" yaml:"cert_file"`
+	KeyFile            string `json:"key_file" yaml:"key_file"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+// BatchSpec configures batch span processor of the exporter.
+type BatchSpec struct {
+	MaxQueueSize       int      `json:"max_queue_size" yaml:"max_queue_size"`
+	MaxExportBatchSize int      `json:"max_export_batch_size" yaml:"max_export_batch_size"`
+	ScheduleDelay      Duration `json:"schedule_delay" yaml:"schedule_delay"`
+	ExportTimeout      Duration `json:"export_timeout" yaml:"export_timeout"`
+}
+
+// SpoolSpec configures disk buffering of the exporter.
+type SpoolSpec struct {
+	Dir        string   `json:"dir" yaml:"dir"`
+	MaxSize    int64    `json:"max_size" yaml:"max_size"`
+	MaxAge     Duration `json:"max_age" yaml:"max_age"`
+	MinBackoff Duration `json:"min_backoff" yaml:"min_backoff"`
+	MaxBackoff Duration `json:"max_backoff" yaml:"max_backoff"`
+}
+
+// Duration is time.Duration which is serialised as a string like "1m30s".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	value, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("parse duration: %w", err)
+	}
+
+	*d = Duration(value)
+
+	return nil
+}
+
+// ConfigurationFromFile loads the ConfigurationSpec from YAML or JSON file and compiles it.
+func ConfigurationFromFile(path string) (*Configuration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var spec ConfigurationSpec
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return nil, fmt.Errorf("%w: decode json: %w", ErrInvalidConfiguration, err)
+		}
+	default:
+		if err := yaml.Unmarshal(data, &spec); err != nil {
+			return nil, fmt.Errorf("%w: decode yaml: %w", ErrInvalidConfiguration, err)
+		}
+	}
+
+	return spec.Configuration()
+}
+
+// UnmarshalYAML decodes the ConfigurationSpec and compiles it into the Configuration.
+func (c *Configuration) UnmarshalYAML(value *yaml.Node) error {
+	var spec ConfigurationSpec
+
+	if err := value.Decode(&spec); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfiguration, err)
+	}
+
+	configuration, err := spec.Configuration()
+	if err != nil {
+		return err
+	}
+
+	*c = *configuration
+
+	return nil
+}
+
+// UnmarshalJSON decodes the ConfigurationSpec and compiles it into the Configuration.
+func (c *Configuration) UnmarshalJSON(data []byte) error {
+	var spec ConfigurationSpec
+
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfiguration, err)
+	}
+
+	configuration, err := spec.Configuration()
+	if err != nil {
+		return err
+	}
+
+	*c = *configuration
+
+	return nil
+}
+
+// Configuration compiles the spec into the Configuration. All exporters from
+// the spec are added to Configuration.Exporters. Tracer is disabled if there are no exporters.
+func (s *ConfigurationSpec) Configuration() (*Configuration, error) {
+	sampler, err := parseSamplerSpec(s.Sampler)
+	if err != nil {
+		return nil, err
+	}
+
+	configuration := &Configuration{
+		ServiceName:       s.ServiceName,
+		Disabled:          s.Disabled || len(s.Exporters) == 0,
+		Sampler:           sampler,
+		Attributes:        attributesFromMap(s.Attributes),
+		BaggageAttributes: s.BaggageAttributes,
+		TailSampling: TailSamplingConfiguration{
+			MaxTraces:        s.TailSampling.MaxTraces,
+			MaxSpansPerTrace: s.TailSampling.MaxSpansPerTrace,
+			MaxAge:           time.Duration(s.TailSampling.MaxAge),
+			Shards:           s.TailSampling.Shards,
+			RateLimit: spanprocessor.RateLimit{
+				PerSecond: s.TailSampling.RateLimit.PerSecond,
+				MinErrors: s.TailSampling.RateLimit.MinErrors,
+				MinSlow:   s.TailSampling.RateLimit.MinSlow,
+				Interval:  time.Duration(s.TailSampling.RateLimit.Interval),
+				MaxKeys:   s.TailSampling.RateLimit.MaxKeys,
+			},
+			PartialFlush: spanprocessor.PartialFlush{
+				OnError:  s.TailSampling.PartialFlush.OnError,
+				MaxSpans: s.TailSampling.PartialFlush.MaxSpans,
+				MaxAge:   time.Duration(s.TailSampling.PartialFlush.MaxAge),
+			},
+			ForceKeep: s.TailSampling.ForceKeep,
+		},
+	}
+
+	configuration.TailSampling.ErrorPredicate, err = parseErrorPredicate(s.TailSampling.Errors.Detect,
+		s.TailSampling.Errors.GRPCCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.TailSampling.RateLimit.PerSecond < 0 {
+		return nil, fmt.Errorf("%w: rate limit must not be negative", ErrInvalidConfiguration)
+	}
+
+	for _, rule := range s.TailSampling.Latency {
+		if rule.Threshold <= 0 {
+			return nil, fmt.Errorf("%w: latency threshold must be positive", ErrInvalidConfiguration)
+		}
+
+		configuration.TailSampling.Latency = append(configuration.TailSampling.Latency, spanprocessor.LatencyRule{
+			Name:      rule.Name,
+			Route:     rule.Route,
+			Threshold: time.Duration(rule.Threshold),
+		})
+	}
+
+	for idx := range s.TailSampling.Policies {
+		policy, err := s.TailSampling.Policies[idx].policy()
+		if err != nil {
+			return nil, fmt.Errorf("policy %d: %w", idx, err)
+		}
+
+		configuration.TailSampling.Policies = append(configuration.TailSampling.Policies, policy)
+	}
+
+	if len(s.Propagators) > 0 {
+		if configuration.Propagator, err = parsePropagators(strings.Join(s.Propagators, ",")); err != nil {
+			return nil, err
+		}
+	}
+
+	if configuration.Detectors, err = parseDetectors(s.Detectors); err != nil {
+		return nil, err
+	}
+
+	for idx := range s.Exporters {
+		exporter, err := s.Exporters[idx].configuration()
+		if err != nil {
+			return nil, fmt.Errorf("exporter %d: %w", idx, err)
+		}
+
+		configuration.Exporters = append(configuration.Exporters, *exporter)
+	}
+
+	if err := configuration.validate(); err != nil {
+		return nil, err
+	}
+
+	return configuration, nil
+}
+
+func (s *ExporterSpec) configuration() (*ExporterConfiguration, error) {
+	configuration := &ExporterConfiguration{
+		Addr:                 s.Addr,
+		Exporter:             s.Exporter,
+		Headers:              s.Headers,
+		Compression:          s.Compression,
+		Timeout:              time.Duration(s.Timeout),
+		Encoding:             s.Encoding,
+		SpanProcessorOptions: s.Batch.options(),
+		Spool: SpoolConfiguration{
+			Dir:        s.Spool.Dir,
+			MaxSize:    s.Spool.MaxSize,
+			MaxAge:     time.Duration(s.Spool.MaxAge),
+			MinBackoff: time.Duration(s.Spool.MinBackoff),
+			MaxBackoff: time.Duration(s.Spool.MaxBackoff),
+		},
+	}
+
+	if s.Sampler != "" {
+		sampler, err := parseSamplerSpec(s.Sampler)
+		if err != nil {
+			return nil, err
+		}
+
+		configuration.Sampler = sampler
+	}
+
+	if s.TLS != nil {
+		config, err := s.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+
+		configuration.TLSConfig = config
+	}
+
+	return configuration, nil
+}
+
+func (s *BatchSpec) options() []tracesdk.BatchSpanProcessorOption {
+	var options []tracesdk.BatchSpanProcessorOption
+
+	if s.MaxQueueSize > 0 {
+		options = append(options, tracesdk.WithMaxQueueSize(s.MaxQueueSize))
+	}
+
+	if s.MaxExportBatchSize > 0 {
+		options = append(options, tracesdk.WithMaxExportBatchSize(s.MaxExportBatchSize))
+	}
+
+	if s.ScheduleDelay > 0 {
+		options = append(options, tracesdk.WithBatchTimeout(time.Duration(s.ScheduleDelay)))
+	}
+
+	if s.ExportTimeout > 0 {
+		options = append(options, tracesdk.WithExportTimeout(time.Duration(s.ExportTimeout)))
+	}
+
+	return options
+}
+
+func (s *TLSSpec) config() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         s.ServerName,
+		InsecureSkipVerify: s.InsecureSkipVerify, //nolint:gosec // configured by user.
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if s.CAFile != "" {
+		data, err := os.ReadFile(s.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: read ca file: %w", ErrInvalidConfiguration, err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%w: no certificates in ca file", ErrInvalidConfiguration)
+		}
+	}
+
+	if s.CertFile != "" || s.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: load key pair: %w", ErrInvalidConfiguration, err)
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+func (s *PolicySpec) policy() (spanprocessor.Policy, error) {
+	policy := spanprocessor.Policy{Name: s.Name}
+
+	switch strings.ToLower(s.Decision) {
+	case "", "keep":
+	case "drop":
+		policy.Drop = true
+	default:
+		return spanprocessor.Policy{}, fmt.Errorf("%w: unknown decision '%s', supported [keep, drop]",
+			ErrInvalidConfiguration, s.Decision)
+	}
+
+	condition, err := s.Condition.condition()
+	if err != nil {
+		return spanprocessor.Policy{}, err
+	}
+
+	policy.Condition = condition
+
+	return policy, nil
+}
+
+//nolint:funlen,gocyclo,cyclop // flat list of conditions.
+func (s *ConditionSpec) condition() (spanprocessor.Condition, error) {
+	var conditions []spanprocessor.Condition
+
+	if len(s.And) > 0 {
+		and, err := conditionList(s.And)
+		if err != nil {
+			return nil, fmt.Errorf("and: %w", err)
+		}
+
+		conditions = append(conditions, spanprocessor.And(and...))
+	}
+
+	if len(s.Or) > 0 {
+		or, err := conditionList(s.Or)
+		if err != nil {
+			return nil, fmt.Errorf("or: %w", err)
+		}
+
+		conditions = append(conditions, spanprocessor.Or(or...))
+	}
+
+	if s.Not != nil {
+		not, err := s.Not.condition()
+		if err != nil {
+			return nil, fmt.Errorf("not: %w", err)
+		}
+
+		conditions = append(conditions, spanprocessor.Not(not))
+	}
+
+	if s.Attribute != nil {
+		condition, err := s.Attribute.condition()
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	if s.StatusCode != "" {
+		code, err := parseStatusCode(s.StatusCode)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, spanprocessor.StatusCode(code))
+	}
+
+	if s.Latency < 0 {
+		return nil, fmt.Errorf("%w: latency must not be negative", ErrInvalidConfiguration)
+	}
+
+	if s.Latency > 0 {
+		conditions = append(conditions, spanprocessor.Latency(time.Duration(s.Latency)))
+	}
+
+	if s.SpanCount != nil {
+		if s.SpanCount.Min < 0 || s.SpanCount.Max < 0 || (s.SpanCount.Max > 0 && s.SpanCount.Max < s.SpanCount.Min) {
+			return nil, fmt.Errorf("%w: invalid span count range [%d, %d]",
+				ErrInvalidConfiguration, s.SpanCount.Min, s.SpanCount.Max)
+		}
+
+		conditions = append(conditions, spanprocessor.SpanCount(s.SpanCount.Min, s.SpanCount.Max))
+	}
+
+	if s.SpanKind != "" {
+		kind, err := parseSpanKind(s.SpanKind)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, spanprocessor.SpanKind(kind))
+	}
+
+	if s.ServiceName != "" {
+		conditions = append(conditions, spanprocessor.ServiceName(s.ServiceName))
+	}
+
+	if s.Probability != nil {
+		if *s.Probability < 0 || *s.Probability > 1 {
+			return nil, fmt.Errorf("%w: probability must be in range [0, 1]", ErrInvalidConfiguration)
+		}
+
+		conditions = append(conditions, spanprocessor.Probabilistic(*s.Probability))
+	}
+
+	if s.RateLimit < 0 {
+		return nil, fmt.Errorf("%w: rate limit must not be negative", ErrInvalidConfiguration)
+	}
+
+	if s.RateLimit > 0 {
+		conditions = append(conditions, spanprocessor.RateLimited(s.RateLimit))
+	}
+
+	return spanprocessor.And(conditions...), nil
+}
+
+func (s *AttributeConditionSpec) condition() (spanprocessor.Condition, error) {
+	if s.Key == "" {
+		return nil, fmt.Errorf("%w: empty attribute key", ErrInvalidConfiguration)
+	}
+
+	switch {
+	case s.Equals != nil:
+		return spanprocessor.AttributeEquals(s.Key, *s.Equals), nil
+	case s.Regex != "":
+		re, err := regexp.Compile(s.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("%w: attribute '%s' regex: %w", ErrInvalidConfiguration, s.Key, err)
+		}
+
+		return spanprocessor.AttributeRegex(s.Key, re), nil
+	case s.Min != nil || s.Max != nil:
+		min, max := math.Inf(-1), math.Inf(1)
+
+		if s.Min != nil {
+			min = *s.Min
+		}
+
+		if s.Max != nil {
+			max = *s.Max
+		}
+
+		return spanprocessor.AttributeRange(s.Key, min, max), nil
+	default:
+		return nil, fmt.Errorf("%w: attribute '%s' needs equals, regex, min or max",
+			ErrInvalidConfiguration, s.Key)
+	}
+}
+
+func conditionList(specs []ConditionSpec) ([]spanprocessor.Condition, error) {
+	conditions := make([]spanprocessor.Condition, 0, len(specs))
+
+	for idx := range specs {
+		condition, err := specs[idx].condition()
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, nil
+}
+
+func parseStatusCode(value string) (codes.Code, error) {
+	for _, code := range []codes.Code{codes.Unset, codes.Error, codes.Ok} {
+		if strings.EqualFold(code.String(), value) {
+			return code, nil
+		}
+	}
+
+	return codes.Unset, fmt.Errorf("%w: unknown status code '%s', supported [unset, error, ok]",
+		ErrInvalidConfiguration, value)
+}
+
+func parseSpanKind(value string) (trace.SpanKind, error) {
+	for _, kind := range []trace.SpanKind{
+		trace.SpanKindInternal,
+		trace.SpanKindServer,
+		trace.SpanKindClient,
+		trace.SpanKindProducer,
+		trace.SpanKindConsumer,
+	} {
+		if strings.EqualFold(kind.String(), value) {
+			return kind, nil
+		}
+	}
+
+	return trace.SpanKindUnspecified, fmt.Errorf("%w: unknown span kind '%s', supported [%s]",
+		ErrInvalidConfiguration, value, "internal, server, client, producer, consumer")
+}
+
+// parseSamplerSpec parses sampler in format "name[:arg]", always_on is used by default.
+func parseSamplerSpec(spec string) (tracesdk.Sampler, error) {
+	if spec == "" {
+		return tracesdk.AlwaysSample(), nil
+	}
+
+	name, arg, _ := strings.Cut(spec, ":")
+
+	return ParseSampler(name, arg)
+}
+
+func attributesFromMap(values map[string]string) []attribute.KeyValue {
+	if len(values) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	attributes := make([]attribute.KeyValue, 0, len(keys))
+
+	for _, key := range keys {
+		attributes = append(attributes, attribute.String(key, values[key]))
+	}
+
+	return attributes
+}
+
+// parseErrorPredicate builds predicate from the names of the built-in predicates.
+func parseErrorPredicate(names []string, grpcCodes []string) (spanprocessor.ErrorPredicate, error) {
+	if len(names) == 0 {
+		if len(grpcCodes) > 0 {
+			return nil, fmt.Errorf("%w: grpc codes are set without grpc error detector", ErrInvalidConfiguration)
+		}
+
+		return nil, nil
+	}
+
+	predicates := make([]spanprocessor.ErrorPredicate, 0, len(names))
+
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case ErrorDetectorStatus:
+			predicates = append(predicates, spanprocessor.StatusError)
+		case ErrorDetectorAttribute:
+			predicates = append(predicates, spanprocessor.ErrorAttribute)
+		case ErrorDetectorException:
+			predicates = append(predicates, spanprocessor.ExceptionEvent)
+		case ErrorDetectorHTTP:
+			predicates = append(predicates, spanprocessor.HTTPServerError)
+		case ErrorDetectorGRPC:
+			codes, err := parseGRPCCodes(grpcCodes)
+			if err != nil {
+				return nil, err
+			}
+
+			predicates = append(predicates, spanprocessor.GRPCStatusCodes(codes...))
+		default:
+			return nil, fmt.Errorf("%w: unknown error detector '%s', supported [%s, %s, %s, %s, %s]",
+				ErrInvalidConfiguration, name, ErrorDetectorStatus, ErrorDetectorAttribute,
+				ErrorDetectorException, ErrorDetectorHTTP, ErrorDetectorGRPC)
+		}
+	}
+
+	return spanprocessor.AnyError(predicates...), nil
+}
+
+// parseGRPCCodes parses codes by names like "internal" or "DEADLINE_EXCEEDED" and by numbers,
+// codes of server side failures are returned if values are empty.
+func parseGRPCCodes(values []string) ([]int64, error) {
+	if len(values) == 0 {
+		return _defaultGRPCErrorCodes, nil
+	}
+
+	result := make([]int64, 0, len(values))
+
+	for _, value := range values {
+		var code grpccodes.Code
+
+		name := strings.ToUpper(strings.TrimSpace(value))
+
+		if _, err := strconv.Atoi(name); err != nil {
+			name = strconv.Quote(name)
+		}
+
+		if err := code.UnmarshalJSON([]byte(name)); err != nil {
+			return nil, fmt.Errorf("%w: unknown grpc code '%s'", ErrInvalidConfiguration, value)
+		}
+
+		result = append(result, int64(code))
+	}
+
+	return result, nil
+}
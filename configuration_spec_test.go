@@ -0,0 +1,326 @@
+package tracing
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gopkg.in/yaml.v3"
+
+	"github.com/loghole/tracing/spanprocessor"
+)
+
+const _specYAML = `
+tracing:
+  service_name: example
+  sampler: parentbased_traceidratio:0.1
+  propagators: [tracecontext, baggage]
+  baggage_attributes: [tenant.id]
+  attributes:
+    deployment.environment: prod
+    team: platform
+  exporters:
+    - addr: otlp+grpc://127.0.0.1:4317
+      compression: gzip
+      timeout: 5s
+      headers:
+        authorization: token
+      tls:
+        insecure_skip_verify: true
+      batch:
+        max_queue_size: 4096
+        schedule_delay: 1s
+    - addr: stdout://
+      sampler: always_off
+  tail_sampling:
+    max_traces: 1000
+    max_age: 1m
+    latency:
+      - threshold: 2s
+      - route: /search
+        threshold: 500ms
+`
+
+func TestConfiguration_UnmarshalYAML(t *testing.T) {
+	var config struct {
+		Tracing Configuration `yaml:"tracing"`
+	}
+
+	require.NoError(t, yaml.Unmarshal([]byte(_specYAML), &config))
+
+	c := config.Tracing
+
+	assert.Equal(t, "example", c.ServiceName)
+	assert.False(t, c.Disabled)
+	assert.Empty(t, c.Addr)
+	assert.Equal(t, tracesdk.ParentBased(tracesdk.TraceIDRatioBased(0.1)).Description(), c.Sampler.Description())
+	assert.NotNil(t, c.Propagator)
+	assert.Equal(t, []string{"tenant.id"}, c.BaggageAttributes)
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("deployment.environment", "prod"),
+		attribute.String("team", "platform"),
+	}, c.Attributes)
+
+	assert.Equal(t, TailSamplingConfiguration{
+		MaxTraces: 1000,
+		MaxAge:    time.Minute,
+		Latency: []spanprocessor.LatencyRule{
+			{Threshold: 2 * time.Second},
+			{Route: "/search", Threshold: 500 * time.Millisecond},
+		},
+	}, c.TailSampling)
+
+	require.Len(t, c.Exporters, 2)
+	assert.Equal(t, "otlp+grpc://127.0.0.1:4317", c.Exporters[0].Addr)
+	assert.Equal(t, "gzip", c.Exporters[0].Compression)
+	assert.Equal(t, 5*time.Second, c.Exporters[0].Timeout)
+	assert.Equal(t, map[string]string{"authorization": "token"}, c.Exporters[0].Headers)
+	assert.True(t, c.Exporters[0].TLSConfig.InsecureSkipVerify)
+	assert.Len(t, c.Exporters[0].SpanProcessorOptions, 2)
+	assert.Nil(t, c.Exporters[0].Sampler)
+	assert.Equal(t, tracesdk.NeverSample(), c.Exporters[1].Sampler)
+}
+
+func TestConfiguration_UnmarshalJSON(t *testing.T) {
+	var c Configuration
+
+	data := `{"service_name": "example", "exporters": [{"addr": "udp://127.0.0.1:6831", "batch": {"export_timeout": "3s"}}]}`
+
+	require.NoError(t, json.Unmarshal([]byte(data), &c))
+
+	assert.Equal(t, "example", c.ServiceName)
+	assert.Equal(t, tracesdk.AlwaysSample(), c.Sampler)
+	require.Len(t, c.Exporters, 1)
+	assert.Equal(t, "udp://127.0.0.1:6831", c.Exporters[0].Addr)
+	assert.Len(t, c.Exporters[0].SpanProcessorOptions, 1)
+}
+
+func TestConfigurationFromFile(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "tracing.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("service_name: example\nexporters:\n  - addr: stdout://\n"), 0o600))
+
+	jsonPath := filepath.Join(dir, "tracing.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"service_name": "example"}`), 0o600))
+
+	c, err := ConfigurationFromFile(yamlPath)
+	require.NoError(t, err)
+	assert.False(t, c.Disabled)
+	assert.Len(t, c.Exporters, 1)
+
+	c, err = ConfigurationFromFile(jsonPath)
+	require.NoError(t, err)
+	assert.True(t, c.Disabled)
+
+	tracer, err := NewTracer(c)
+	require.NoError(t, err)
+	assert.NoError(t, tracer.Close())
+}
+
+func TestConfigurationSpec_Policies(t *testing.T) {
+	data := `
+service_name: example
+tail_sampling:
+  policies:
+    - name: drop-health
+      decision: drop
+      condition:
+        attribute: {key: http.route, regex: ^/health}
+    - name: keep-errors
+      condition:
+        and:
+          - status_code: error
+          - span_kind: server
+          - attribute: {key: http.status_code, min: 500}
+    - name: sample
+      condition:
+        or:
+          - probability: 0.1
+          - rate_limit: 5
+  rate_limit:
+    per_second: 10
+    min_errors: 2
+    interval: 1m
+  partial_flush:
+    on_error: true
+    max_age: 5m
+  force_keep: true
+`
+
+	var c Configuration
+
+	require.NoError(t, yaml.Unmarshal([]byte(data), &c))
+	require.Len(t, c.TailSampling.Policies, 3)
+
+	assert.Equal(t, "drop-health", c.TailSampling.Policies[0].Name)
+	assert.True(t, c.TailSampling.Policies[0].Drop)
+	assert.Equal(t, "keep-errors", c.TailSampling.Policies[1].Name)
+	assert.False(t, c.TailSampling.Policies[1].Drop)
+	assert.Equal(t, spanprocessor.RateLimit{PerSecond: 10, MinErrors: 2, Interval: time.Minute}, c.TailSampling.RateLimit)
+	assert.Equal(t, spanprocessor.PartialFlush{OnError: true, MaxAge: 5 * time.Minute}, c.TailSampling.PartialFlush)
+	assert.True(t, c.TailSampling.ForceKeep)
+	assert.Len(t, c.TailSampling.options(), 4)
+}
+
+func TestConfigurationSpec_Configuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{
+			name:    "empty service name",
+			spec:    `{}`,
+			wantErr: "invalid configuration: empty service name",
+		},
+		{
+			name:    "invalid sampler",
+			spec:    `{"service_name": "example", "sampler": "traceidratio:x"}`,
+			wantErr: "invalid configuration: parse sampler arg 'x': strconv.ParseFloat: parsing \"x\": invalid syntax",
+		},
+		{
+			name:    "invalid exporter sampler",
+			spec:    `{"service_name": "example", "exporters": [{"addr": "stdout://", "sampler": "never"}]}`,
+			wantErr: "exporter 0: invalid configuration: unknown sampler 'never'",
+		},
+		{
+			name:    "invalid duration",
+			spec:    `{"service_name": "example", "exporters": [{"addr": "stdout://", "timeout": "soon"}]}`,
+			wantErr: "invalid configuration: parse duration: time: invalid duration \"soon\"",
+		},
+		{
+			name:    "invalid latency threshold",
+			spec:    `{"service_name": "example", "tail_sampling": {"latency": [{"route": "/search"}]}}`,
+			wantErr: "invalid configuration: latency threshold must be positive",
+		},
+		{
+			name:    "invalid policy decision",
+			spec:    `{"service_name": "example", "tail_sampling": {"policies": [{"decision": "maybe"}]}}`,
+			wantErr: "policy 0: invalid configuration: unknown decision 'maybe', supported [keep, drop]",
+		},
+		{
+			name:    "invalid policy regex",
+			spec:    `{"service_name": "example", "tail_sampling": {"policies": [{"condition": {"not": {"attribute": {"key": "a", "regex": "("}}}}]}}`,
+			wantErr: "policy 0: not: invalid configuration: attribute 'a' regex: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name:    "invalid policy span kind",
+			spec:    `{"service_name": "example", "tail_sampling": {"policies": [{"condition": {"or": [{"span_kind": "x"}]}}]}}`,
+			wantErr: "policy 0: or: invalid configuration: unknown span kind 'x', supported [internal, server, client, producer, consumer]",
+		},
+		{
+			name:    "invalid policy probability",
+			spec:    `{"service_name": "example", "tail_sampling": {"policies": [{"condition": {"probability": 2}}]}}`,
+			wantErr: "policy 0: invalid configuration: probability must be in range [0, 1]",
+		},
+		{
+			name:    "negative rate limit",
+			spec:    `{"service_name": "example", "tail_sampling": {"rate_limit": {"per_second": -1}}}`,
+			wantErr: "invalid configuration: rate limit must not be negative",
+		},
+		{
+			name:    "invalid tls",
+			spec:    `{"service_name": "example", "exporters": [{"addr": "stdout://", "tls": {"ca_file": "/not/exists"}}]}`,
+			wantErr: "exporter 0: invalid configuration: read ca file: open /not/exists: no such file or directory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Configuration
+
+			err := json.Unmarshal([]byte(tt.spec), &c)
+			assert.ErrorIs(t, err, ErrInvalidConfiguration)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseErrorPredicate(t *testing.T) {
+	tests := []struct {
+		name      string
+		detect    []string
+		grpcCodes []string
+		span      tracetest.SpanStub
+		want      bool
+		wantErr   string
+	}{
+		{
+			name:   "status",
+			detect: []string{"status"},
+			span:   tracetest.SpanStub{Status: tracesdk.Status{Code: codes.Error}},
+			want:   true,
+		},
+		{
+			name:   "http",
+			detect: []string{"status", "HTTP"},
+			span:   tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Int("http.status_code", 502)}},
+			want:   true,
+		},
+		{
+			name:   "grpc default codes",
+			detect: []string{"grpc"},
+			span:   tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Int("rpc.grpc.status_code", 14)}},
+			want:   true,
+		},
+		{
+			name:   "grpc default codes not found",
+			detect: []string{"grpc"},
+			span:   tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Int("rpc.grpc.status_code", 5)}},
+			want:   false,
+		},
+		{
+			name:      "grpc codes",
+			detect:    []string{"grpc"},
+			grpcCodes: []string{"internal", "5"},
+			span:      tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Int("rpc.grpc.status_code", 5)}},
+			want:      true,
+		},
+		{
+			name:      "grpc code not in set",
+			detect:    []string{"grpc"},
+			grpcCodes: []string{"DEADLINE_EXCEEDED"},
+			span:      tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Int("rpc.grpc.status_code", 14)}},
+			want:      false,
+		},
+		{
+			name:    "unknown detector",
+			detect:  []string{"panic"},
+			wantErr: "invalid configuration: unknown error detector 'panic', supported [status, attribute, exception, http, grpc]",
+		},
+		{
+			name:      "unknown grpc code",
+			detect:    []string{"grpc"},
+			grpcCodes: []string{"broken"},
+			wantErr:   "invalid configuration: unknown grpc code 'broken'",
+		},
+		{
+			name:      "grpc codes without detector",
+			grpcCodes: []string{"internal"},
+			wantErr:   "invalid configuration: grpc codes are set without grpc error detector",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predicate, err := parseErrorPredicate(tt.detect, tt.grpcCodes)
+			if tt.wantErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidConfiguration)
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, predicate(tt.span.Snapshot()))
+		})
+	}
+}
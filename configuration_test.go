@@ -3,10 +3,14 @@ package tracing
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/attribute"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/loghole/tracing/spanprocessor"
 )
 
 func TestDefaultConfiguration(t *testing.T) {
@@ -125,6 +129,16 @@ func TestConfiguration_endpoint(t *testing.T) {
 			c:       &Configuration{Addr: "udp://127.0.0.1:6543"},
 			wantErr: assert.NoError,
 		},
+		{
+			name:    "jaeger",
+			c:       &Configuration{Addr: "jaeger://127.0.0.1:14268/api/traces"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "without scheme",
+			c:       &Configuration{Addr: "127.0.0.1:6831", Exporter: ExporterJaeger},
+			wantErr: assert.NoError,
+		},
 		{
 			name: "error",
 			c:    &Configuration{Addr: ""},
@@ -137,7 +151,10 @@ func TestConfiguration_endpoint(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.c.endpoint()
+			u, err := tt.c.addr()
+			require.NoError(t, err)
+
+			got, err := tt.c.endpoint(u)
 			if !tt.wantErr(t, err, fmt.Sprintf("endpoint()")) {
 				return
 			}
@@ -146,3 +163,15 @@ func TestConfiguration_endpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestTailSamplingConfiguration_options(t *testing.T) {
+	assert.Empty(t, (&TailSamplingConfiguration{}).options())
+	assert.Len(t, (&TailSamplingConfiguration{ForceKeep: true}).options(), 1)
+	assert.Len(t, (&TailSamplingConfiguration{
+		MaxTraces:        1,
+		MaxSpansPerTrace: 1,
+		MaxAge:           time.Second,
+		Shards:           1,
+		Latency:          []spanprocessor.LatencyRule{{Threshold: time.Second}},
+	}).options(), 5)
+}
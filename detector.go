@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+
+	"github.com/loghole/tracing/internal/detector"
+)
+
+// Resource detector names, they are used in the Configuration spec.
+const (
+	DetectorHost       = "host"
+	DetectorProcess    = "process"
+	DetectorBuild      = "build"
+	DetectorContainer  = "container"
+	DetectorKubernetes = "k8s"
+)
+
+// HostDetector detects host.name.
+func HostDetector() resource.Detector { return detector.Host{} }
+
+// ProcessDetector detects process.pid, process.executable.name and process.runtime.* attributes.
+func ProcessDetector() resource.Detector { return detector.Process{} }
+
+// BuildDetector detects service.version from the main module version of the binary.
+func BuildDetector() resource.Detector { return detector.Build{} }
+
+// ContainerDetector detects container.id from the cgroup files.
+func ContainerDetector() resource.Detector { return detector.Container{} }
+
+// KubernetesDetector detects k8s.pod.name, k8s.pod.uid, k8s.namespace.name and k8s.node.name
+// from the K8S_POD_NAME, K8S_POD_UID, K8S_NAMESPACE_NAME and K8S_NODE_NAME environment variables
+// which should be set by the downward API. POD_NAME, POD_UID, POD_NAMESPACE and NODE_NAME are used as fallback.
+func KubernetesDetector() resource.Detector { return detector.Kubernetes{} }
+
+// DefaultDetectors returns all available resource detectors.
+func DefaultDetectors() []resource.Detector {
+	return []resource.Detector{
+		HostDetector(),
+		ProcessDetector(),
+		BuildDetector(),
+		ContainerDetector(),
+		KubernetesDetector(),
+	}
+}
+
+// resource returns resource with detected attributes, the Attributes and the ServiceName take priority.
+func (c *Configuration) resource(ctx context.Context) (*resource.Resource, error) {
+	explicit := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		append(c.Attributes, semconv.ServiceNameKey.String(c.ServiceName))...,
+	)
+
+	if len(c.Detectors) == 0 {
+		return explicit, nil
+	}
+
+	// Partial resource is used as is, it contains all attributes which were detected.
+	detected, err := resource.New(ctx, resource.WithDetectors(c.Detectors...))
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, fmt.Errorf("detect resource: %w", err)
+	}
+
+	result, err := resource.Merge(detected, explicit)
+	if err != nil {
+		return nil, fmt.Errorf("merge resource: %w", err)
+	}
+
+	return result, nil
+}
+
+func parseDetectors(names []string) ([]resource.Detector, error) {
+	var detectors []resource.Detector
+
+	for _, name := range names {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case DetectorHost:
+			detectors = append(detectors, HostDetector())
+		case DetectorProcess:
+			detectors = append(detectors, ProcessDetector())
+		case DetectorBuild:
+			detectors = append(detectors, BuildDetector())
+		case DetectorContainer:
+			detectors = append(detectors, ContainerDetector())
+		case DetectorKubernetes:
+			detectors = append(detectors, KubernetesDetector())
+		default:
+			return nil, fmt.Errorf("%w: unknown detector '%s', supported [%s, %s, %s, %s, %s]", ErrInvalidConfiguration,
+				name, DetectorHost, DetectorProcess, DetectorBuild, DetectorContainer, DetectorKubernetes)
+		}
+	}
+
+	return detectors, nil
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+type detectorFunc func(ctx context.Context) (*resource.Resource, error)
+
+func (f detectorFunc) Detect(ctx context.Context) (*resource.Resource, error) {
+	return f(ctx)
+}
+
+func staticDetector(attributes ...attribute.KeyValue) resource.Detector {
+	return detectorFunc(func(context.Context) (*resource.Resource, error) {
+		return resource.NewWithAttributes(semconv.SchemaURL, attributes...), nil
+	})
+}
+
+func TestConfiguration_resource(t *testing.T) {
+	configuration := DefaultConfiguration("explicit", "")
+	configuration.Attributes = []attribute.KeyValue{semconv.ServiceVersionKey.String("v1.0.0")}
+	configuration.Detectors = []resource.Detector{
+		staticDetector(
+			semconv.HostNameKey.String("host-1"),
+			semconv.ServiceNameKey.String("detected"),
+			semconv.ServiceVersionKey.String("v0.0.1"),
+		),
+		staticDetector(semconv.K8SPodNameKey.String("pod-1")),
+	}
+
+	got, err := configuration.resource(context.Background())
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		semconv.HostNameKey.String("host-1"),
+		semconv.K8SPodNameKey.String("pod-1"),
+		semconv.ServiceNameKey.String("explicit"),
+		semconv.ServiceVersionKey.String("v1.0.0"),
+	}, got.Attributes())
+}
+
+func TestConfiguration_resourceDefaultDetectors(t *testing.T) {
+	configuration := DefaultConfiguration("explicit", "")
+	configuration.Detectors = DefaultDetectors()
+
+	got, err := configuration.resource(context.Background())
+	require.NoError(t, err)
+
+	for _, key := range []attribute.Key{semconv.HostNameKey, semconv.ProcessPIDKey, semconv.ProcessRuntimeVersionKey} {
+		_, ok := got.Set().Value(key)
+		assert.True(t, ok, key)
+	}
+}
+
+func TestParseDetectors(t *testing.T) {
+	detectors, err := parseDetectors([]string{"host", " K8S "})
+	require.NoError(t, err)
+	assert.Equal(t, []resource.Detector{HostDetector(), KubernetesDetector()}, detectors)
+
+	_, err = parseDetectors([]string{"cloud"})
+	assert.ErrorIs(t, err, ErrInvalidConfiguration)
+	assert.EqualError(t, err, "invalid configuration: unknown detector 'cloud', supported [host, process, build, container, k8s]")
+}
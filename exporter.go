@@ -0,0 +1,318 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/loghole/tracing/internal/fileexporter"
+	"github.com/loghole/tracing/internal/otlpjson"
+	"github.com/loghole/tracing/internal/spool"
+	"github.com/loghole/tracing/spanprocessor"
+)
+
+const (
+	_compressionGzip     = "gzip"
+	_defaultOTLPHTTPPath = "/v1/traces"
+	_defaultZipkinPath   = "/api/v2/spans"
+)
+
+// processor builds batch span processors for all configured exporters.
+func (c *Configuration) processor(ctx context.Context) (tracesdk.SpanProcessor, error) {
+	branches := make([]spanprocessor.Branch, 0, len(c.Exporters)+1)
+
+	if c.hasPrimaryExporter() {
+		exporter, err := c.exporter(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		branches = append(branches, spanprocessor.Branch{
+			Processor: tracesdk.NewBatchSpanProcessor(exporter, c.SpanProcessorOptions...),
+		})
+	}
+
+	for idx := range c.Exporters {
+		exporter, err := c.withExporter(&c.Exporters[idx]).exporter(ctx)
+		if err != nil {
+			for _, branch := range branches {
+				_ = branch.Processor.Shutdown(ctx)
+			}
+
+			return nil, fmt.Errorf("exporter %d: %w", idx, err)
+		}
+
+		branches = append(branches, spanprocessor.Branch{
+			Processor: tracesdk.NewBatchSpanProcessor(exporter, c.Exporters[idx].SpanProcessorOptions...),
+			Sampler:   c.Exporters[idx].Sampler,
+			Filter:    c.Exporters[idx].Filter,
+		})
+	}
+
+	if len(branches) == 1 {
+		return branches[0].Processor, nil
+	}
+
+	return spanprocessor.NewFanout(branches...), nil
+}
+
+func (c *Configuration) exporter(ctx context.Context) (tracesdk.SpanExporter, error) {
+	u, err := c.addr()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := c.scheme(u)
+
+	factory, ok := lookupExporter(scheme)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown exporter '%s', supported [%s]",
+			ErrInvalidConfiguration, scheme, strings.Join(registeredSchemes(), ", "))
+	}
+
+	exporter, err := factory(ctx, u, c)
+	if err != nil {
+		return nil, fmt.Errorf("init %s exporter: %w", scheme, err)
+	}
+
+	if c.Spool.Dir == "" {
+		return exporter, nil
+	}
+
+	spooled, err := spool.New(exporter, spool.Config{
+		Dir:        c.Spool.Dir,
+		MaxSize:    c.Spool.MaxSize,
+		MaxAge:     c.Spool.MaxAge,
+		MinBackoff: c.Spool.MinBackoff,
+		MaxBackoff: c.Spool.MaxBackoff,
+		Timeout:    c.Timeout,
+	})
+	if err != nil {
+		_ = exporter.Shutdown(ctx)
+
+		return nil, fmt.Errorf("init spool: %w", err)
+	}
+
+	return spooled, nil
+}
+
+// addr parses Addr, an address without scheme is allowed when the exporter is set explicitly.
+func (c *Configuration) addr() (*url.URL, error) {
+	addr := c.Addr
+
+	if c.Exporter != "" && !strings.Contains(addr, "://") {
+		addr = "//" + addr
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("parse addr: %w", err)
+	}
+
+	return u, nil
+}
+
+// scheme returns key of the exporter factory, the Exporter takes priority over the Addr scheme.
+func (c *Configuration) scheme(u *url.URL) string {
+	if c.Exporter != "" {
+		return strings.ToLower(c.Exporter)
+	}
+
+	return strings.ToLower(u.Scheme)
+}
+
+func jaegerExporter(_ context.Context, u *url.URL, c *Configuration) (tracesdk.SpanExporter, error) {
+	endpoint, err := c.endpoint(u)
+	if err != nil {
+		return nil, err
+	}
+
+	return jaeger.New(endpoint)
+}
+
+func otlpGRPCExporter(ctx context.Context, u *url.URL, c *Configuration) (tracesdk.SpanExporter, error) {
+	options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(u.Host)}
+
+	if c.TLSConfig != nil {
+		options = append(options, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(c.TLSConfig)))
+	} else {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+
+	if len(c.Headers) > 0 {
+		options = append(options, otlptracegrpc.WithHeaders(c.Headers))
+	}
+
+	if c.Timeout > 0 {
+		options = append(options, otlptracegrpc.WithTimeout(c.Timeout))
+	}
+
+	gzip, err := c.gzip()
+	if err != nil {
+		return nil, err
+	}
+
+	if gzip {
+		options = append(options, otlptracegrpc.WithCompressor(_compressionGzip))
+	}
+
+	return otlptracegrpc.New(ctx, options...)
+}
+
+func otlpHTTPExporter(ctx context.Context, u *url.URL, c *Configuration) (tracesdk.SpanExporter, error) {
+	gzip, err := c.gzip()
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(c.Encoding) {
+	case "", EncodingProtobuf:
+	case EncodingJSON:
+		scheme := "http"
+		if c.TLSConfig != nil {
+			scheme = "https"
+		}
+
+		return otlptrace.New(ctx, otlpjson.NewClient(otlpjson.Config{
+			URL:       (&url.URL{Scheme: scheme, Host: u.Host, Path: urlPath(u, _defaultOTLPHTTPPath)}).String(),
+			Headers:   c.Headers,
+			Gzip:      gzip,
+			Timeout:   c.Timeout,
+			TLSConfig: c.TLSConfig,
+		}))
+	default:
+		return nil, fmt.Errorf("%w: unknown encoding '%s', supported [%s, %s]",
+			ErrInvalidConfiguration, c.Encoding, EncodingProtobuf, EncodingJSON)
+	}
+
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(urlPath(u, _defaultOTLPHTTPPath)),
+	}
+
+	if c.TLSConfig != nil {
+		options = append(options, otlptracehttp.WithTLSClientConfig(c.TLSConfig))
+	} else {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	if len(c.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(c.Headers))
+	}
+
+	if c.Timeout > 0 {
+		options = append(options, otlptracehttp.WithTimeout(c.Timeout))
+	}
+
+	if gzip {
+		options = append(options, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+	}
+
+	return otlptracehttp.New(ctx, options...)
+}
+
+func zipkinExporter(_ context.Context, u *url.URL, c *Configuration) (tracesdk.SpanExporter, error) {
+	scheme := "http"
+	if strings.HasSuffix(strings.ToLower(u.Scheme), "https") || c.TLSConfig != nil {
+		scheme = "https"
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // default transport.
+	transport.TLSClientConfig = c.TLSConfig
+
+	client := &http.Client{
+		Transport: &headersTransport{base: transport, headers: c.Headers},
+		Timeout:   c.Timeout,
+	}
+
+	collectorURL := &url.URL{Scheme: scheme, Host: u.Host, Path: urlPath(u, _defaultZipkinPath), RawQuery: u.RawQuery}
+
+	return zipkin.New(collectorURL.String(), zipkin.WithClient(client))
+}
+
+// fileExporter writes spans to the file. Output is configured with query params:
+//   - format: json (default) or tree;
+//   - max_size: file size in bytes after which it will be rotated;
+//   - max_backups: number of rotated files to keep.
+//
+// Example: file://path/spans.jsonl?format=json&max_size=10485760&max_backups=3.
+func fileExporter(_ context.Context, u *url.URL, _ *Configuration) (tracesdk.SpanExporter, error) {
+	var (
+		query  = u.Query()
+		config = fileexporter.Config{Format: strings.ToLower(query.Get("format"))}
+		err    error
+	)
+
+	if config.Path = u.Host + u.Path; config.Path == "" {
+		return nil, fmt.Errorf("%w: empty file path", ErrInvalidConfiguration)
+	}
+
+	if value := query.Get("max_size"); value != "" {
+		if config.MaxSize, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: parse max_size: %w", ErrInvalidConfiguration, err)
+		}
+	}
+
+	if value := query.Get("max_backups"); value != "" {
+		if config.MaxBackups, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("%w: parse max_backups: %w", ErrInvalidConfiguration, err)
+		}
+	}
+
+	return fileexporter.New(config)
+}
+
+func stdoutExporter(_ context.Context, u *url.URL, _ *Configuration) (tracesdk.SpanExporter, error) {
+	return fileexporter.New(fileexporter.Config{Format: strings.ToLower(u.Query().Get("format"))})
+}
+
+func urlPath(u *url.URL, defaultPath string) string {
+	if u.Path == "" || u.Path == "/" {
+		return defaultPath
+	}
+
+	return u.Path
+}
+
+func (c *Configuration) gzip() (bool, error) {
+	switch strings.ToLower(c.Compression) {
+	case "", "none":
+		return false, nil
+	case _compressionGzip:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: unknown compression '%s', supported [%s]",
+			ErrInvalidConfiguration, c.Compression, _compressionGzip)
+	}
+}
+
+// headersTransport sets custom headers to each request.
+type headersTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headersTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.headers) == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,595 @@
+package tracing
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+type collectorStub struct {
+	collectortracepb.UnimplementedTraceServiceServer
+
+	mu       sync.Mutex
+	spans    []*tracepb.Span
+	metadata metadata.MD
+}
+
+func newCollectorStub(t *testing.T) (*collectorStub, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var (
+		collector = &collectorStub{}
+		server    = grpc.NewServer()
+	)
+
+	collectortracepb.RegisterTraceServiceServer(server, collector)
+
+	go func() { _ = server.Serve(listener) }()
+
+	t.Cleanup(server.Stop)
+
+	return collector, listener.Addr().String()
+}
+
+func (s *collectorStub) Export(
+	ctx context.Context,
+	req *collectortracepb.ExportTraceServiceRequest,
+) (*collectortracepb.ExportTraceServiceResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.metadata, _ = metadata.FromIncomingContext(ctx)
+
+	for _, resourceSpans := range req.GetResourceSpans() {
+		for _, scopeSpans := range resourceSpans.GetScopeSpans() {
+			s.spans = append(s.spans, scopeSpans.GetSpans()...)
+		}
+	}
+
+	return &collectortracepb.ExportTraceServiceResponse{}, nil
+}
+
+func (s *collectorStub) Spans() []*tracepb.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.spans
+}
+
+func TestNewTracer_OTLPGRPC(t *testing.T) {
+	collector, addr := newCollectorStub(t)
+
+	configuration := DefaultConfiguration("test", "otlp+grpc://"+addr)
+	configuration.Headers = map[string]string{"authorization": "token"}
+	configuration.Compression = "gzip"
+
+	tracer, err := NewTracer(configuration)
+	require.NoError(t, err)
+
+	tracer.NewSpan().WithName("root").Start(context.Background()).End()
+
+	require.NoError(t, tracer.Close())
+
+	spans := collector.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "root", spans[0].GetName())
+	assert.Equal(t, []string{"token"}, collector.metadata.Get("authorization"))
+}
+
+func TestConfiguration_scheme(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *Configuration
+		want string
+	}{
+		{
+			name: "jaeger udp",
+			c:    &Configuration{Addr: "udp://127.0.0.1:6831"},
+			want: "udp",
+		},
+		{
+			name: "otlp grpc",
+			c:    &Configuration{Addr: "OTLP+GRPC://127.0.0.1:4317"},
+			want: ExporterOTLPGRPC,
+		},
+		{
+			name: "zipkin https",
+			c:    &Configuration{Addr: "zipkin+https://127.0.0.1:9411/api/v2/spans"},
+			want: "zipkin+https",
+		},
+		{
+			name: "explicit exporter",
+			c:    &Configuration{Addr: "127.0.0.1:4317", Exporter: "OTLP+GRPC"},
+			want: ExporterOTLPGRPC,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := tt.c.addr()
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, tt.c.scheme(u))
+			assert.Equal(t, "127.0.0.1", u.Hostname())
+
+			_, ok := lookupExporter(tt.want)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestConfiguration_exporter(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *Configuration
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "jaeger",
+			c:       &Configuration{Addr: "udp://127.0.0.1:6831"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "jaeger without scheme",
+			c:       &Configuration{Addr: "127.0.0.1:6831", Exporter: ExporterJaeger},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "otlp grpc",
+			c:       &Configuration{Addr: "otlp+grpc://127.0.0.1:4317", Timeout: 1},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "otlp http",
+			c:       &Configuration{Addr: "otlp+http://127.0.0.1:4318", Encoding: EncodingJSON},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "zipkin",
+			c:       &Configuration{Addr: "zipkin+https://127.0.0.1:9411"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "stdout",
+			c:       &Configuration{Addr: "stdout://?format=tree"},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "file without path",
+			c:    &Configuration{Addr: "file://"},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrInvalidConfiguration)
+			},
+		},
+		{
+			name: "unknown encoding",
+			c:    &Configuration{Addr: "otlp+http://127.0.0.1:4318", Encoding: "xml"},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrInvalidConfiguration)
+			},
+		},
+		{
+			name: "unknown compression",
+			c:    &Configuration{Addr: "otlp+grpc://127.0.0.1:4317", Compression: "zstd"},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrInvalidConfiguration)
+			},
+		},
+		{
+			name: "unknown scheme",
+			c:    &Configuration{Addr: "tcp://127.0.0.1:4317"},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrInvalidConfiguration)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter, err := tt.c.exporter(context.Background())
+			if !tt.wantErr(t, err) || err != nil {
+				return
+			}
+
+			assert.NoError(t, exporter.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestNewTracer_OTLPHTTP(t *testing.T) {
+	tests := []struct {
+		name        string
+		encoding    string
+		contentType string
+		decode      func(data []byte, req *collectortracepb.ExportTraceServiceRequest) error
+	}{
+		{
+			name:        "protobuf",
+			encoding:    EncodingProtobuf,
+			contentType: "application/x-protobuf",
+			decode: func(data []byte, req *collectortracepb.ExportTraceServiceRequest) error {
+				return proto.Unmarshal(data, req)
+			},
+		},
+		{
+			name:        "json",
+			encoding:    EncodingJSON,
+			contentType: "application/json",
+			decode: func(data []byte, req *collectortracepb.ExportTraceServiceRequest) error {
+				var obj struct {
+					ResourceSpans []struct {
+						ScopeSpans []struct {
+							Spans []struct {
+								Name    string `json:"name"`
+								TraceID string `json:"traceId"`
+								Kind    int32  `json:"kind"`
+								Status  struct {
+									Code int32 `json:"code"`
+								} `json:"status"`
+							} `json:"spans"`
+						} `json:"scopeSpans"`
+					} `json:"resourceSpans"`
+				}
+
+				if err := json.Unmarshal(data, &obj); err != nil {
+					return err
+				}
+
+				for _, resourceSpans := range obj.ResourceSpans {
+					for _, scopeSpans := range resourceSpans.ScopeSpans {
+						for _, span := range scopeSpans.Spans {
+							traceID, err := hex.DecodeString(span.TraceID)
+							if err != nil {
+								return err
+							}
+
+							req.ResourceSpans = append(req.ResourceSpans, &tracepb.ResourceSpans{
+								ScopeSpans: []*tracepb.ScopeSpans{{
+									Spans: []*tracepb.Span{{
+										Name:    span.Name,
+										TraceId: traceID,
+										Kind:    tracepb.Span_SpanKind(span.Kind),
+										Status:  &tracepb.Status{Code: tracepb.Status_StatusCode(span.Status.Code)},
+									}},
+								}},
+							})
+						}
+					}
+				}
+
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu    sync.Mutex
+				spans []*tracepb.Span
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/traces", r.URL.Path)
+				assert.Equal(t, "token", r.Header.Get("Authorization"))
+				assert.Equal(t, tt.contentType, r.Header.Get("Content-Type"))
+				assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+
+				reader, err := gzip.NewReader(r.Body)
+				require.NoError(t, err)
+
+				data, err := io.ReadAll(reader)
+				require.NoError(t, err)
+
+				var req collectortracepb.ExportTraceServiceRequest
+
+				require.NoError(t, tt.decode(data, &req))
+
+				mu.Lock()
+				for _, resourceSpans := range req.GetResourceSpans() {
+					for _, scopeSpans := range resourceSpans.GetScopeSpans() {
+						spans = append(spans, scopeSpans.GetSpans()...)
+					}
+				}
+				mu.Unlock()
+
+				w.Header().Set("Content-Type", tt.contentType)
+			}))
+			defer server.Close()
+
+			configuration := DefaultConfiguration("test", strings.Replace(server.URL, "http", "otlp+http", 1)+"/v1/traces")
+			configuration.Headers = map[string]string{"Authorization": "token"}
+			configuration.Compression = "gzip"
+			configuration.Encoding = tt.encoding
+
+			tracer, err := NewTracer(configuration)
+			require.NoError(t, err)
+
+			span := tracer.NewSpan().WithName("root").WithKind(trace.SpanKindServer).Start(context.Background())
+			span.SetStatus(codes.Error, "error")
+			span.End()
+
+			require.NoError(t, tracer.Close())
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			require.Len(t, spans, 1)
+			assert.Equal(t, "root", spans[0].GetName())
+			assert.Equal(t, span.SpanContext().TraceID().String(), hex.EncodeToString(spans[0].GetTraceId()))
+			assert.Equal(t, tracepb.Span_SPAN_KIND_SERVER, spans[0].GetKind())
+			assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, spans[0].GetStatus().GetCode())
+		})
+	}
+}
+
+func TestNewTracer_JaegerScheme(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/traces", r.URL.Path)
+		assert.Equal(t, "application/x-thrift", r.Header.Get("Content-Type"))
+
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	tracer, err := NewTracer(DefaultConfiguration("test", strings.Replace(server.URL, "http", "jaeger", 1)+"/api/traces"))
+	require.NoError(t, err)
+
+	tracer.NewSpan().WithName("root").Start(context.Background()).End()
+
+	require.NoError(t, tracer.Close())
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestNewTracer_Zipkin(t *testing.T) {
+	type endpoint struct {
+		ServiceName string `json:"serviceName"`
+	}
+
+	type zipkinSpan struct {
+		Name           string            `json:"name"`
+		Kind           string            `json:"kind"`
+		LocalEndpoint  *endpoint         `json:"localEndpoint"`
+		RemoteEndpoint *endpoint         `json:"remoteEndpoint"`
+		Tags           map[string]string `json:"tags"`
+	}
+
+	var (
+		mu    sync.Mutex
+		spans []zipkinSpan
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2/spans", r.URL.Path)
+		assert.Equal(t, "token", r.Header.Get("Authorization"))
+
+		var batch []zipkinSpan
+
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+
+		mu.Lock()
+		spans = append(spans, batch...)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	configuration := DefaultConfiguration("test", strings.Replace(server.URL, "http", "zipkin", 1))
+	configuration.Headers = map[string]string{"Authorization": "token"}
+
+	tracer, err := NewTracer(configuration)
+	require.NoError(t, err)
+
+	_, span := tracer.Start(context.Background(), "HTTP GET /",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(http.MethodGet),
+			semconv.NetPeerNameKey.String("backend"),
+		),
+	)
+	span.End()
+
+	require.NoError(t, tracer.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, spans, 1)
+	assert.Equal(t, "http get /", spans[0].Name) // zipkin lowercases span names.
+	assert.Equal(t, "CLIENT", spans[0].Kind)
+	assert.Equal(t, &endpoint{ServiceName: "test"}, spans[0].LocalEndpoint)
+	assert.Equal(t, &endpoint{ServiceName: "backend"}, spans[0].RemoteEndpoint)
+	assert.Equal(t, http.MethodGet, spans[0].Tags["http.method"])
+}
+
+func TestNewTracer_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+
+	tracer, err := NewTracer(DefaultConfiguration("test", "file://"+path+"?format=json&max_size=1048576"))
+	require.NoError(t, err)
+
+	tracer.NewSpan().WithName("root").Start(context.Background()).End()
+
+	require.NoError(t, tracer.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var span struct {
+		Name string `json:"name"`
+	}
+
+	require.NoError(t, json.Unmarshal(data, &span))
+	assert.Equal(t, "root", span.Name)
+}
+
+func TestNewTracer_Exporters(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		primary = filepath.Join(dir, "primary.jsonl")
+		second  = filepath.Join(dir, "second.jsonl")
+	)
+
+	configuration := DefaultConfiguration("test", "file://"+primary)
+	configuration.Exporters = []ExporterConfiguration{
+		{
+			Addr:   "file://" + second,
+			Filter: func(span tracesdk.ReadOnlySpan) bool { return span.Name() == "root" },
+		},
+	}
+
+	tracer, err := NewTracer(configuration)
+	require.NoError(t, err)
+
+	ctx, span := tracer.NewSpan().WithName("root").StartWithContext(context.Background())
+	tracer.NewSpan().WithName("child").Start(ctx).End()
+	span.End()
+
+	require.NoError(t, tracer.Close())
+
+	for path, count := range map[string]int{primary: 2, second: 1} {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), count, path)
+	}
+}
+
+func TestConfiguration_withExporter(t *testing.T) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	tests := []struct {
+		name        string
+		exporter    ExporterConfiguration
+		wantHeaders map[string]string
+		wantTLS     *tls.Config
+	}{
+		{
+			name:     "same host",
+			exporter: ExporterConfiguration{Addr: "otlp+http://collector:4318", Timeout: time.Minute},
+			wantTLS:  tlsConfig,
+		},
+		{
+			name:     "other host",
+			exporter: ExporterConfiguration{Addr: "otlp+http://other:4318", Timeout: time.Minute},
+		},
+		{
+			name: "own settings",
+			exporter: ExporterConfiguration{
+				Addr:      "otlp+http://other:4318",
+				Timeout:   time.Minute,
+				Headers:   map[string]string{"other": "value"},
+				TLSConfig: &tls.Config{MinVersion: tls.VersionTLS13},
+			},
+			wantHeaders: map[string]string{"other": "value"},
+			wantTLS:     &tls.Config{MinVersion: tls.VersionTLS13},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Configuration{
+				ServiceName: "test",
+				Addr:        "otlp+grpc://collector:4317",
+				Headers:     map[string]string{"key": "value"},
+				TLSConfig:   tlsConfig,
+				Timeout:     time.Second,
+				Spool:       SpoolConfiguration{Dir: "/tmp/spool"},
+				Exporters:   []ExporterConfiguration{tt.exporter},
+			}
+
+			got := c.withExporter(&c.Exporters[0])
+
+			assert.Equal(t, tt.exporter.Addr, got.Addr)
+			assert.Equal(t, tt.wantHeaders, got.Headers)
+			assert.Equal(t, tt.wantTLS, got.TLSConfig)
+			assert.Equal(t, time.Minute, got.Timeout)
+			assert.Empty(t, got.Spool.Dir)
+			assert.Nil(t, got.Exporters)
+		})
+	}
+}
+
+// failingExporter fails export until it is enabled.
+type failingExporter struct {
+	memoryExporter
+
+	enabled atomic.Bool
+}
+
+func (e *failingExporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	if !e.enabled.Load() {
+		return errors.New("collector is down")
+	}
+
+	return e.memoryExporter.ExportSpans(ctx, spans)
+}
+
+func TestNewTracer_Spool(t *testing.T) {
+	downstream := &failingExporter{memoryExporter: memoryExporter{tracetest.NewInMemoryExporter()}}
+
+	RegisterExporter("test+failing", func(context.Context, *url.URL, *Configuration) (tracesdk.SpanExporter, error) {
+		return downstream, nil
+	})
+
+	t.Cleanup(func() {
+		_exportersMu.Lock()
+		delete(_exporters, "test+failing")
+		_exportersMu.Unlock()
+	})
+
+	dir := t.TempDir()
+
+	configuration := DefaultConfiguration("test", "test+failing://collector")
+	configuration.Spool = SpoolConfiguration{Dir: dir, MinBackoff: 10 * time.Millisecond}
+
+	tracer, err := NewTracer(configuration)
+	require.NoError(t, err)
+
+	_, span := tracer.Start(context.Background(), "root")
+	span.End()
+
+	require.NoError(t, tracer.provider.(*tracesdk.TracerProvider).ForceFlush(context.Background()))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Empty(t, downstream.GetSpans())
+
+	downstream.enabled.Store(true)
+
+	assert.Eventually(t, func() bool { return len(downstream.GetSpans()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "root", downstream.GetSpans()[0].Name)
+
+	require.NoError(t, tracer.Close())
+}
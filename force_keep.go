@@ -0,0 +1,22 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/loghole/tracing/internal/forcekeep"
+)
+
+// ForceKeepHeader is the response header which asks the upstream service to keep the trace.
+const ForceKeepHeader = forcekeep.Header
+
+// ForceKeep asks tail samplers of this and other services to keep the whole trace.
+// The flag is sent downstream in the tracestate and upstream in the ForceKeepHeader
+// by tracehttp and tracegrpc middlewares. It does nothing unless TailSampling.ForceKeep is enabled.
+func ForceKeep(ctx context.Context) {
+	forcekeep.Set(ctx)
+}
+
+// IsForceKept reports whether the trace of the context must be kept.
+func IsForceKept(ctx context.Context) bool {
+	return forcekeep.IsSet(ctx)
+}
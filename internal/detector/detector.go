@@ -0,0 +1,208 @@
+// Package detector contains resource detectors of the host, process, container and kubernetes pod.
+package detector
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+const (
+	_cgroupPath    = "/proc/self/cgroup"
+	_mountInfoPath = "/proc/self/mountinfo"
+)
+
+var _containerIDRegexp = regexp.MustCompile(`[0-9a-f]{64}`)
+
+var (
+	_ resource.Detector = Host{}
+	_ resource.Detector = Process{}
+	_ resource.Detector = Build{}
+	_ resource.Detector = Container{}
+	_ resource.Detector = Kubernetes{}
+)
+
+// Host detects host.name.
+type Host struct{}
+
+func (Host) Detect(context.Context) (*resource.Resource, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("get hostname: %w", err)
+	}
+
+	return resource.NewWithAttributes(semconv.SchemaURL, semconv.HostNameKey.String(name)), nil
+}
+
+// Process detects process.pid, process.executable.name and process.runtime.* attributes.
+type Process struct{}
+
+func (Process) Detect(context.Context) (*resource.Resource, error) {
+	return resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ProcessPIDKey.Int(os.Getpid()),
+		semconv.ProcessExecutableNameKey.String(filepath.Base(os.Args[0])),
+		semconv.ProcessRuntimeNameKey.String(runtime.Compiler),
+		semconv.ProcessRuntimeVersionKey.String(runtime.Version()),
+		semconv.ProcessRuntimeDescriptionKey.String("go version "+runtime.Version()+" "+runtime.GOOS+"/"+runtime.GOARCH),
+	), nil
+}
+
+// Build detects service.version from the main module version of the binary.
+// Nothing is detected for binaries built without module information or from a local checkout.
+type Build struct{}
+
+func (Build) Detect(context.Context) (*resource.Resource, error) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return resource.Empty(), nil
+	}
+
+	if version := info.Main.Version; version != "" && version != "(devel)" {
+		return resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceVersionKey.String(version)), nil
+	}
+
+	return resource.Empty(), nil
+}
+
+// Container detects container.id from the cgroup files, nothing is detected outside of a container.
+type Container struct {
+	// CgroupPath and MountInfoPath are used in tests, /proc/self files by default.
+	CgroupPath    string
+	MountInfoPath string
+}
+
+func (c Container) Detect(context.Context) (*resource.Resource, error) {
+	cgroupPath, mountInfoPath := c.CgroupPath, c.MountInfoPath
+
+	if cgroupPath == "" {
+		cgroupPath = _cgroupPath
+	}
+
+	if mountInfoPath == "" {
+		mountInfoPath = _mountInfoPath
+	}
+
+	// cgroup v1 contains id in the cgroup path, cgroup v2 hides it, so mounts of the container runtime are checked.
+	id, err := scanFile(cgroupPath, containerIDFromCgroup)
+	if err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		if id, err = scanFile(mountInfoPath, containerIDFromMountInfo); err != nil {
+			return nil, err
+		}
+	}
+
+	if id == "" {
+		return resource.Empty(), nil
+	}
+
+	return resource.NewWithAttributes(semconv.SchemaURL, semconv.ContainerIDKey.String(id)), nil
+}
+
+// Kubernetes detects k8s.* attributes from the environment variables set by the downward API.
+//
+// Example of the pod spec:
+//
+//	env:
+//	  - name: K8S_POD_NAME
+//	    valueFrom:
+//	      fieldRef:
+//	        fieldPath: metadata.name
+//	  - name: K8S_NAMESPACE_NAME
+//	    valueFrom:
+//	      fieldRef:
+//	        fieldPath: metadata.namespace
+type Kubernetes struct{}
+
+func (Kubernetes) Detect(context.Context) (*resource.Resource, error) {
+	fields := []struct {
+		key  attribute.Key
+		envs []string
+	}{
+		{key: semconv.K8SPodNameKey, envs: []string{"K8S_POD_NAME", "POD_NAME"}},
+		{key: semconv.K8SPodUIDKey, envs: []string{"K8S_POD_UID", "POD_UID"}},
+		{key: semconv.K8SNamespaceNameKey, envs: []string{"K8S_NAMESPACE_NAME", "POD_NAMESPACE"}},
+		{key: semconv.K8SNodeNameKey, envs: []string{"K8S_NODE_NAME", "NODE_NAME"}},
+	}
+
+	var attributes []attribute.KeyValue
+
+	for _, field := range fields {
+		for _, env := range field.envs {
+			if value := os.Getenv(env); value != "" {
+				attributes = append(attributes, field.key.String(value))
+
+				break
+			}
+		}
+	}
+
+	if len(attributes) == 0 {
+		return resource.Empty(), nil
+	}
+
+	return resource.NewWithAttributes(semconv.SchemaURL, attributes...), nil
+}
+
+func scanFile(path string, parse func(line string) string) (string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("open %s: %w", path, err)
+	}
+
+	defer file.Close()
+
+	return scan(file, parse)
+}
+
+func scan(r io.Reader, parse func(line string) string) (string, error) {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		if id := parse(scanner.Text()); id != "" {
+			return id, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("scan: %w", err)
+	}
+
+	return "", nil
+}
+
+// containerIDFromCgroup parses lines like "0::/kubepods/burstable/pod.../docker-<id>.scope".
+func containerIDFromCgroup(line string) string {
+	path := line[strings.LastIndex(line, ":")+1:]
+
+	return _containerIDRegexp.FindString(path[strings.LastIndex(path, "/")+1:])
+}
+
+// containerIDFromMountInfo parses lines with runtime mounts like "/var/lib/docker/containers/<id>/hostname".
+func containerIDFromMountInfo(line string) string {
+	for _, field := range strings.Fields(line) {
+		if _, rest, ok := strings.Cut(field, "/containers/"); ok {
+			if id := _containerIDRegexp.FindString(rest); id != "" && strings.HasPrefix(rest, id) {
+				return id
+			}
+		}
+	}
+
+	return ""
+}
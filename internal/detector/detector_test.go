@@ -0,0 +1,101 @@
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+const _containerID = "4b6ea5a19b3dd4ac3a4e4e3bd1e1b7d1fba3e2a4d7c2f5f9c3b81c4a3b2e8f01"
+
+func TestContainer_Detect(t *testing.T) {
+	tests := []struct {
+		name      string
+		cgroup    string
+		mountInfo string
+		want      []attribute.KeyValue
+	}{
+		{
+			name:   "cgroup v1 docker",
+			cgroup: "12:pids:/docker/" + _containerID + "\n11:memory:/docker/" + _containerID + "\n",
+			want:   []attribute.KeyValue{semconv.ContainerIDKey.String(_containerID)},
+		},
+		{
+			name:   "cgroup v1 kubernetes systemd",
+			cgroup: "1:name=systemd:/kubepods.slice/kubepods-pod1.slice/cri-containerd-" + _containerID + ".scope\n",
+			want:   []attribute.KeyValue{semconv.ContainerIDKey.String(_containerID)},
+		},
+		{
+			name:      "cgroup v2",
+			cgroup:    "0::/\n",
+			mountInfo: "736 719 0:112 /docker/containers/" + _containerID + "/hostname /etc/hostname rw,relatime - ext4 /dev/sda1 rw\n",
+			want:      []attribute.KeyValue{semconv.ContainerIDKey.String(_containerID)},
+		},
+		{
+			name:      "not in container",
+			cgroup:    "0::/user.slice/user-1000.slice/session-2.scope\n",
+			mountInfo: "22 1 8:1 / / rw,relatime - ext4 /dev/sda1 rw\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			detector := Container{
+				CgroupPath:    filepath.Join(dir, "cgroup"),
+				MountInfoPath: filepath.Join(dir, "mountinfo"),
+			}
+
+			require.NoError(t, os.WriteFile(detector.CgroupPath, []byte(tt.cgroup), 0o600))
+			require.NoError(t, os.WriteFile(detector.MountInfoPath, []byte(tt.mountInfo), 0o600))
+
+			got, err := detector.Detect(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Attributes())
+		})
+	}
+}
+
+func TestContainer_DetectNoFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	got, err := Container{
+		CgroupPath:    filepath.Join(dir, "cgroup"),
+		MountInfoPath: filepath.Join(dir, "mountinfo"),
+	}.Detect(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, got.Attributes())
+}
+
+func TestKubernetes_Detect(t *testing.T) {
+	t.Setenv("K8S_POD_NAME", "")
+	t.Setenv("POD_NAME", "api-6d4cf56db6-8x2lp")
+	t.Setenv("K8S_POD_UID", "")
+	t.Setenv("POD_UID", "")
+	t.Setenv("K8S_NAMESPACE_NAME", "prod")
+	t.Setenv("POD_NAMESPACE", "ignored")
+	t.Setenv("K8S_NODE_NAME", "")
+	t.Setenv("NODE_NAME", "")
+
+	got, err := Kubernetes{}.Detect(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		semconv.K8SPodNameKey.String("api-6d4cf56db6-8x2lp"),
+		semconv.K8SNamespaceNameKey.String("prod"),
+	}, got.Attributes())
+}
+
+func TestProcess_Detect(t *testing.T) {
+	got, err := Process{}.Detect(context.Background())
+	require.NoError(t, err)
+
+	value, ok := got.Set().Value(semconv.ProcessPIDKey)
+	assert.True(t, ok)
+	assert.Equal(t, int64(os.Getpid()), value.AsInt64())
+}
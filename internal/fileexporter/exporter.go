@@ -0,0 +1,149 @@
+package fileexporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/loghole/tracing/internal/spancodec"
+)
+
+// Supported output formats.
+const (
+	FormatJSON = "json"
+	FormatTree = "tree"
+)
+
+var ErrUnknownFormat = errors.New("unknown format")
+
+var _ tracesdk.SpanExporter = new(Exporter)
+
+// Config configures Exporter.
+type Config struct {
+	// Path to the output file, stdout is used if empty.
+	Path string
+	// Format of the output: line-delimited JSON or human-readable tree per trace.
+	Format string
+	// MaxSize is the file size in bytes after which it will be rotated, zero disables rotation.
+	MaxSize int64
+	// MaxBackups is the number of rotated files to keep.
+	MaxBackups int
+}
+
+// Exporter writes finished spans to the file or stdout.
+type Exporter struct {
+	format string
+	output io.Writer
+	closer io.Closer
+
+	mu sync.Mutex
+}
+
+func New(config Config) (*Exporter, error) {
+	switch config.Format {
+	case "":
+		config.Format = FormatJSON
+	case FormatJSON, FormatTree:
+	default:
+		return nil, fmt.Errorf("%w '%s', supported [%s, %s]", ErrUnknownFormat, config.Format, FormatJSON, FormatTree)
+	}
+
+	exporter := &Exporter{format: config.Format}
+
+	if config.Path == "" {
+		exporter.output = os.Stdout
+
+		return exporter, nil
+	}
+
+	writer, err := newRotatingWriter(config.Path, config.MaxSize, config.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter.output = writer
+	exporter.closer = writer
+
+	return exporter, nil
+}
+
+// NewWithWriter returns Exporter that writes to the custom writer.
+func NewWithWriter(writer io.Writer, format string) (*Exporter, error) {
+	exporter, err := New(Config{Format: format})
+	if err != nil {
+		return nil, err
+	}
+
+	exporter.output = writer
+
+	return exporter, nil
+}
+
+func (e *Exporter) ExportSpans(_ context.Context, spans []tracesdk.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.output == nil {
+		return nil
+	}
+
+	converted := make([]*spancodec.Span, 0, len(spans))
+
+	for _, span := range spans {
+		converted = append(converted, spancodec.FromReadOnly(span))
+	}
+
+	// Each batch is written by one call to avoid rotation in the middle of a trace.
+	var buf bytes.Buffer
+
+	if e.format == FormatTree {
+		writeTree(&buf, converted)
+	} else if err := writeJSON(&buf, converted); err != nil {
+		return err
+	}
+
+	if _, err := e.output.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("write spans: %w", err)
+	}
+
+	return nil
+}
+
+func (e *Exporter) Shutdown(_ context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.output = nil
+
+	if e.closer == nil {
+		return nil
+	}
+
+	closer := e.closer
+	e.closer = nil
+
+	return closer.Close()
+}
+
+func writeJSON(writer io.Writer, spans []*spancodec.Span) error {
+	encoder := json.NewEncoder(writer)
+
+	for _, span := range spans {
+		if err := encoder.Encode(span); err != nil {
+			return fmt.Errorf("encode span: %w", err)
+		}
+	}
+
+	return nil
+}
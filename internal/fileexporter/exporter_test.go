@@ -0,0 +1,155 @@
+package fileexporter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/loghole/tracing/internal/spancodec"
+)
+
+func TestExporter_JSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	exporter, err := NewWithWriter(&buf, FormatJSON)
+	require.NoError(t, err)
+
+	spans := makeTrace(t)
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans))
+	require.NoError(t, exporter.Shutdown(context.Background()))
+
+	scanner := bufio.NewScanner(&buf)
+
+	var decoded []spancodec.Span
+
+	for scanner.Scan() {
+		var span spancodec.Span
+
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &span))
+
+		decoded = append(decoded, span)
+	}
+
+	require.Len(t, decoded, 3)
+	assert.Equal(t, "child2", decoded[0].Name)
+	assert.Equal(t, spans[0].SpanContext().TraceID().String(), decoded[0].TraceID)
+	assert.Equal(t, spans[0].Parent().SpanID().String(), decoded[0].ParentSpanID)
+	assert.Equal(t, []spancodec.Attribute{{Key: "key", Type: "STRING", Value: "value"}}, decoded[0].Attributes)
+	assert.Equal(t, "Error", decoded[1].Status.Code)
+}
+
+func TestExporter_Tree(t *testing.T) {
+	var buf bytes.Buffer
+
+	exporter, err := NewWithWriter(&buf, FormatTree)
+	require.NoError(t, err)
+
+	spans := makeTrace(t)
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "trace "+spans[0].SpanContext().TraceID().String(), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "└─ root ["), lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "   ├─ child1 ["), lines[2])
+	assert.Contains(t, lines[2], "ERROR \"failed\"")
+	assert.True(t, strings.HasPrefix(lines[3], "   └─ child2 ["), lines[3])
+	assert.Contains(t, lines[3], "key=value")
+}
+
+func TestExporter_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+
+	exporter, err := New(Config{Path: path, MaxSize: 1, MaxBackups: 2})
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, exporter.ExportSpans(context.Background(), makeTrace(t)))
+	}
+
+	require.NoError(t, exporter.Shutdown(context.Background()))
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 3, name)
+	}
+
+	assert.NoFileExists(t, path+".3")
+}
+
+func TestRotatingWriter_RenameError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+
+	writer, err := newRotatingWriter(path, 4, 1)
+	require.NoError(t, err)
+
+	defer writer.Close()
+
+	// The file cannot be renamed to the not empty directory.
+	require.NoError(t, os.MkdirAll(filepath.Join(path+".1", "dir"), 0o750))
+
+	_, err = writer.Write([]byte("abc\n"))
+	require.NoError(t, err)
+
+	_, err = writer.Write([]byte("def\n"))
+	require.Error(t, err)
+
+	require.NoError(t, os.RemoveAll(path+".1"))
+
+	_, err = writer.Write([]byte("ghi\n"))
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ghi\n", string(data))
+
+	data, err = os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, "abc\n", string(data))
+}
+
+func TestNew_UnknownFormat(t *testing.T) {
+	_, err := New(Config{Format: "xml"})
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func makeTrace(t *testing.T) []tracesdk.ReadOnlySpan {
+	t.Helper()
+
+	var (
+		recorder = tracetest.NewSpanRecorder()
+		tracer   = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder)).Tracer("test")
+		now      = time.Now()
+	)
+
+	ctx, root := tracer.Start(context.Background(), "root", trace.WithTimestamp(now))
+
+	_, child1 := tracer.Start(ctx, "child1", trace.WithTimestamp(now.Add(time.Millisecond)))
+	child1.SetStatus(codes.Error, "failed")
+
+	_, child2 := tracer.Start(ctx, "child2", trace.WithTimestamp(now.Add(2*time.Millisecond)))
+	child2.SetAttributes(attribute.String("key", "value"))
+
+	child2.End(trace.WithTimestamp(now.Add(3 * time.Millisecond)))
+	child1.End(trace.WithTimestamp(now.Add(4 * time.Millisecond)))
+	root.End(trace.WithTimestamp(now.Add(5 * time.Millisecond)))
+
+	return recorder.Ended()
+}
@@ -0,0 +1,111 @@
+package fileexporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loghole/tracing/internal/spancodec"
+)
+
+type node struct {
+	span     *spancodec.Span
+	children []*node
+}
+
+// writeTree writes spans grouped by trace, children are nested under their parents.
+// Spans whose parent is not in the batch are printed as roots.
+func writeTree(writer io.Writer, spans []*spancodec.Span) {
+	var (
+		traces = make(map[string][]*spancodec.Span)
+		order  = make([]string, 0)
+	)
+
+	for _, span := range spans {
+		if _, ok := traces[span.TraceID]; !ok {
+			order = append(order, span.TraceID)
+		}
+
+		traces[span.TraceID] = append(traces[span.TraceID], span)
+	}
+
+	for _, traceID := range order {
+		fmt.Fprintf(writer, "trace %s\n", traceID)
+
+		roots := buildTree(traces[traceID])
+
+		for idx, root := range roots {
+			writeNode(writer, root, "", idx == len(roots)-1)
+		}
+	}
+}
+
+func buildTree(spans []*spancodec.Span) []*node {
+	nodes := make(map[string]*node, len(spans))
+
+	for _, span := range spans {
+		nodes[span.SpanID] = &node{span: span}
+	}
+
+	roots := make([]*node, 0, 1)
+
+	for _, span := range spans {
+		current := nodes[span.SpanID]
+
+		if parent, ok := nodes[span.ParentSpanID]; ok && span.ParentSpanID != "" {
+			parent.children = append(parent.children, current)
+		} else {
+			roots = append(roots, current)
+		}
+	}
+
+	sortNodes(roots)
+
+	for _, n := range nodes {
+		sortNodes(n.children)
+	}
+
+	return roots
+}
+
+func sortNodes(nodes []*node) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].span.StartTime.Before(nodes[j].span.StartTime)
+	})
+}
+
+func writeNode(writer io.Writer, n *node, prefix string, last bool) {
+	branch, indent := "├─ ", "│  "
+	if last {
+		branch, indent = "└─ ", "   "
+	}
+
+	fmt.Fprintf(writer, "%s%s%s\n", prefix, branch, describe(n.span))
+
+	for idx, child := range n.children {
+		writeNode(writer, child, prefix+indent, idx == len(n.children)-1)
+	}
+}
+
+func describe(span *spancodec.Span) string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "%s [%s] %s %s", span.Name, span.SpanID, strings.ToLower(span.Kind),
+		span.Duration().Round(time.Microsecond))
+
+	if span.Status.Code == "Error" {
+		builder.WriteString(" ERROR")
+
+		if span.Status.Description != "" {
+			fmt.Fprintf(&builder, " %q", span.Status.Description)
+		}
+	}
+
+	for _, attr := range span.Attributes {
+		fmt.Fprintf(&builder, " %s=%v", attr.Key, attr.Value)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,120 @@
+package fileexporter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const _filePerm = 0o644
+
+// rotatingWriter writes to the file and rotates it when the size limit is reached.
+// Rotated files are renamed to `<path>.1`, `<path>.2` and so on, the oldest is removed.
+type rotatingWriter struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+func newRotatingWriter(path string, maxSize int64, maxBackups int) (*rotatingWriter, error) {
+	writer := &rotatingWriter{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	if err := writer.open(); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	return n, err
+}
+
+func (w *rotatingWriter) Close() error {
+	return w.file.Close()
+}
+
+func (w *rotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, _filePerm)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return fmt.Errorf("stat file: %w", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+
+	return nil
+}
+
+// rotate moves the current file to backups and opens the new one. The current file is reopened
+// if it cannot be moved, so the next writes do not fail and retry the rotation.
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return w.reopen(fmt.Errorf("close file: %w", err))
+	}
+
+	if err := w.moveToBackups(); err != nil {
+		return w.reopen(err)
+	}
+
+	return w.open()
+}
+
+func (w *rotatingWriter) moveToBackups() error {
+	if w.maxBackups <= 0 {
+		if err := os.Remove(w.path); err != nil {
+			return fmt.Errorf("remove file: %w", err)
+		}
+
+		return nil
+	}
+
+	for i := w.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(w.backupPath(i), w.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("rename backup: %w", err)
+		}
+	}
+
+	if err := os.Rename(w.path, w.backupPath(1)); err != nil {
+		return fmt.Errorf("rename file: %w", err)
+	}
+
+	return nil
+}
+
+// reopen opens the current file again after the failed rotation and returns the rotation error.
+func (w *rotatingWriter) reopen(err error) error {
+	if openErr := w.open(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+
+	return err
+}
+
+func (w *rotatingWriter) backupPath(idx int) string {
+	return w.path + "." + strconv.Itoa(idx)
+}
@@ -0,0 +1,149 @@
+// Package forcekeep propagates the decision to keep the whole trace between services.
+//
+// The flag is sent downstream as the tracestate entry and upstream as the response header,
+// services which receive it keep their part of the trace regardless of the sampler decision.
+// The flag is ignored and not propagated until it is enabled, so untrusted clients cannot force it.
+package forcekeep
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// Header is the response header which asks the upstream service to keep the trace.
+	Header = "Trace-Force-Keep"
+	// TraceStateKey and TraceStateValue are the tracestate entry which asks downstream services to keep the trace.
+	TraceStateKey   = "loghole"
+	TraceStateValue = "keep"
+
+	_traceParentHeader = "traceparent"
+	_traceStateHeader  = "tracestate"
+	_headerValue       = "1"
+)
+
+// AttributeKey marks spans of the trace which must be kept by the tail sampler.
+const AttributeKey = attribute.Key("sampling.force_keep")
+
+//nolint:gochecknoglobals // the setting is shared by the middlewares like the propagator.
+var _enabled atomic.Bool
+
+// SetEnabled turns the flag handling on or off, it is off by default.
+func SetEnabled(enabled bool) {
+	_enabled.Store(enabled)
+}
+
+// Enabled reports whether the flag is handled.
+func Enabled() bool {
+	return _enabled.Load()
+}
+
+type flagKey struct{}
+
+// flag is shared by all spans of the request in this process.
+type flag struct {
+	set atomic.Bool
+}
+
+// WithFlag returns context with the request flag, the flag of the parent context is reused.
+// The context is returned as is if the flag is not enabled.
+func WithFlag(ctx context.Context) context.Context {
+	if !Enabled() {
+		return ctx
+	}
+
+	if _, ok := ctx.Value(flagKey{}).(*flag); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, flagKey{}, &flag{})
+}
+
+// Set marks the current span and the request to keep the trace.
+func Set(ctx context.Context) {
+	if !Enabled() {
+		return
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(AttributeKey.Bool(true))
+
+	SetFlag(ctx)
+}
+
+// SetFlag marks only the request, so the decision is sent to other services
+// but the current span is not changed.
+func SetFlag(ctx context.Context) {
+	if f, ok := ctx.Value(flagKey{}).(*flag); ok {
+		f.set.Store(true)
+	}
+}
+
+// IsSet reports whether the trace must be kept, by the request flag or by the tracestate of the current span.
+func IsSet(ctx context.Context) bool {
+	if !Enabled() {
+		return false
+	}
+
+	if f, ok := ctx.Value(flagKey{}).(*flag); ok && f.set.Load() {
+		return true
+	}
+
+	return InTraceState(trace.SpanContextFromContext(ctx).TraceState())
+}
+
+// InTraceState reports whether the tracestate contains the flag.
+func InTraceState(state trace.TraceState) bool {
+	return state.Get(TraceStateKey) == TraceStateValue
+}
+
+// Inject adds the flag to the tracestate of the carrier if the trace must be kept, the flag
+// received from upstream is removed if it is not enabled. It must be called after the trace context
+// is injected. Nothing is changed if the propagator does not use the tracecontext format.
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	if !trace.SpanContextFromContext(ctx).IsValid() || carrier.Get(_traceParentHeader) == "" {
+		return
+	}
+
+	state, err := trace.ParseTraceState(carrier.Get(_traceStateHeader))
+	if err != nil {
+		return
+	}
+
+	switch {
+	case IsSet(ctx):
+		if state, err = state.Insert(TraceStateKey, TraceStateValue); err != nil {
+			return
+		}
+	case InTraceState(state) && !Enabled():
+		state = state.Delete(TraceStateKey)
+	default:
+		return
+	}
+
+	carrier.Set(_traceStateHeader, state.String())
+}
+
+// SetHeader sets the response header if the trace must be kept.
+func SetHeader(ctx context.Context, header http.Header) {
+	if IsSet(ctx) {
+		header.Set(Header, _headerValue)
+	}
+}
+
+// FromHeader marks the current span and the request if the response header is set.
+func FromHeader(ctx context.Context, header http.Header) {
+	if HeaderValue(header.Get(Header)) {
+		Set(ctx)
+	}
+}
+
+// HeaderValue reports whether the header value asks to keep the trace.
+func HeaderValue(value string) bool {
+	return strings.TrimSpace(value) == _headerValue
+}
@@ -0,0 +1,155 @@
+package forcekeep
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// enable turns the flag handling on for the test.
+func enable(t *testing.T) {
+	t.Helper()
+
+	SetEnabled(true)
+	t.Cleanup(func() { SetEnabled(false) })
+}
+
+func TestSet(t *testing.T) {
+	enable(t)
+
+	var (
+		recorder = tracetest.NewSpanRecorder()
+		tracer   = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder)).Tracer("")
+	)
+
+	ctx, span := tracer.Start(WithFlag(context.Background()), "server")
+
+	childCtx, child := tracer.Start(ctx, "client")
+
+	assert.False(t, IsSet(ctx))
+
+	Set(childCtx)
+	child.End()
+	span.End()
+
+	assert.True(t, IsSet(ctx), "flag is shared by the request")
+	assert.Contains(t, recorder.Ended()[0].Attributes(), AttributeKey.Bool(true))
+	assert.NotContains(t, recorder.Ended()[1].Attributes(), AttributeKey.Bool(true))
+
+	header := http.Header{}
+	SetHeader(ctx, header)
+	assert.Equal(t, "1", header.Get(Header))
+}
+
+func TestInject(t *testing.T) {
+	enable(t)
+
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		carrier propagation.MapCarrier
+		want    propagation.MapCarrier
+	}{
+		{
+			name:    "not set",
+			ctx:     WithFlag(trace.ContextWithSpanContext(context.Background(), spanCtx)),
+			carrier: propagation.MapCarrier{"traceparent": "tp", "tracestate": "a=b"},
+			want:    propagation.MapCarrier{"traceparent": "tp", "tracestate": "a=b"},
+		},
+		{
+			name: "set",
+			ctx: func() context.Context {
+				ctx := WithFlag(trace.ContextWithSpanContext(context.Background(), spanCtx))
+				Set(ctx)
+
+				return ctx
+			}(),
+			carrier: propagation.MapCarrier{"traceparent": "tp", "tracestate": "a=b"},
+			want:    propagation.MapCarrier{"traceparent": "tp", "tracestate": "loghole=keep,a=b"},
+		},
+		{
+			name: "from tracestate",
+			ctx: func() context.Context {
+				state, _ := trace.TraceState{}.Insert(TraceStateKey, TraceStateValue)
+
+				return trace.ContextWithSpanContext(context.Background(), spanCtx.WithTraceState(state))
+			}(),
+			carrier: propagation.MapCarrier{"traceparent": "tp"},
+			want:    propagation.MapCarrier{"traceparent": "tp", "tracestate": "loghole=keep"},
+		},
+		{
+			name: "other propagator",
+			ctx: func() context.Context {
+				ctx := WithFlag(trace.ContextWithSpanContext(context.Background(), spanCtx))
+				Set(ctx)
+
+				return ctx
+			}(),
+			carrier: propagation.MapCarrier{"b3": "1-1-1"},
+			want:    propagation.MapCarrier{"b3": "1-1-1"},
+		},
+		{
+			name: "invalid span context",
+			ctx: func() context.Context {
+				ctx := WithFlag(context.Background())
+				Set(ctx)
+
+				return ctx
+			}(),
+			carrier: propagation.MapCarrier{},
+			want:    propagation.MapCarrier{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Inject(tt.ctx, tt.carrier)
+
+			assert.Equal(t, tt.want, tt.carrier)
+		})
+	}
+}
+
+func TestFromHeader(t *testing.T) {
+	enable(t)
+
+	ctx := WithFlag(context.Background())
+
+	FromHeader(ctx, http.Header{})
+	assert.False(t, IsSet(ctx))
+
+	FromHeader(ctx, http.Header{Header: []string{"1"}})
+	assert.True(t, IsSet(ctx))
+}
+
+func TestDisabled(t *testing.T) {
+	var (
+		state, _ = trace.TraceState{}.Insert(TraceStateKey, TraceStateValue)
+		spanCtx  = trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{1},
+			SpanID:     trace.SpanID{1},
+			TraceState: state,
+		})
+		ctx = WithFlag(trace.ContextWithSpanContext(context.Background(), spanCtx))
+	)
+
+	FromHeader(ctx, http.Header{Header: []string{"1"}})
+	Set(ctx)
+	assert.False(t, IsSet(ctx))
+
+	header := http.Header{}
+	SetHeader(ctx, header)
+	assert.Empty(t, header)
+
+	carrier := propagation.MapCarrier{"traceparent": "tp", "tracestate": "loghole=keep,a=b"}
+	Inject(ctx, carrier)
+	assert.Equal(t, propagation.MapCarrier{"traceparent": "tp", "tracestate": "a=b"}, carrier)
+}
@@ -0,0 +1,221 @@
+// Package jaegerpropagator propagates the trace context in the format of jaeger clients.
+//
+// The trace context is sent in the uber-trace-id header as {trace-id}:{span-id}:{parent-span-id}:{flags}
+// and baggage members are sent in the uberctx-{key} headers. The debug flag is mapped to the force-keep
+// decision, so debug traces are kept by tail samplers of all services.
+package jaegerpropagator
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/loghole/tracing/internal/forcekeep"
+)
+
+const (
+	// TraceContextHeader is the header of the trace context.
+	TraceContextHeader = "uber-trace-id"
+	// BaggageHeaderPrefix is the prefix of baggage headers.
+	BaggageHeaderPrefix = "uberctx-"
+
+	_flagSampled = 0x01
+	_flagDebug   = 0x02
+
+	_traceIDHexLen = 32
+	_spanIDHexLen  = 16
+)
+
+var (
+	ErrMalformedHeader  = errors.New("malformed uber-trace-id header")
+	ErrInvalidTraceID   = errors.New("invalid trace id")
+	ErrInvalidSpanID    = errors.New("invalid span id")
+	ErrInvalidTraceFlag = errors.New("invalid trace flags")
+)
+
+var _ propagation.TextMapPropagator = Propagator{}
+
+// Propagator reads and writes uber-trace-id and uberctx-* headers.
+type Propagator struct{}
+
+// Inject sets the trace context and baggage from the Context into the carrier.
+func (p Propagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return
+	}
+
+	var flags byte
+
+	if spanCtx.IsSampled() {
+		flags |= _flagSampled
+	}
+
+	if forcekeep.IsSet(ctx) {
+		flags |= _flagDebug | _flagSampled
+	}
+
+	carrier.Set(TraceContextHeader, fmt.Sprintf("%s:%s:0:%x", formatTraceID(spanCtx.TraceID()), spanCtx.SpanID(), flags))
+
+	for _, member := range baggage.FromContext(ctx).Members() {
+		carrier.Set(BaggageHeaderPrefix+member.Key(), url.QueryEscape(member.Value()))
+	}
+}
+
+// Extract reads the trace context and baggage from the carrier into the Context.
+func (p Propagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	ctx = extractBaggage(ctx, carrier)
+
+	header := carrier.Get(TraceContextHeader)
+	if header == "" {
+		return ctx
+	}
+
+	spanCtx, err := Parse(header)
+	if err != nil {
+		return ctx
+	}
+
+	return trace.ContextWithRemoteSpanContext(ctx, spanCtx)
+}
+
+// Fields returns the trace context header, baggage headers are not known in advance.
+func (p Propagator) Fields() []string {
+	return []string{TraceContextHeader}
+}
+
+// Parse parses the uber-trace-id header value, the debug flag is stored in the tracestate
+// as the force-keep entry.
+func Parse(header string) (trace.SpanContext, error) {
+	// Jaeger clients url-encode the value in http headers.
+	if value, err := url.QueryUnescape(header); err == nil {
+		header = value
+	}
+
+	parts := strings.Split(header, ":")
+	if len(parts) != 4 { //nolint:gomnd // trace id, span id, parent span id and flags.
+		return trace.SpanContext{}, ErrMalformedHeader
+	}
+
+	traceID, err := parseTraceID(parts[0])
+	if err != nil {
+		return trace.SpanContext{}, err
+	}
+
+	spanID, err := parseSpanID(parts[1])
+	if err != nil {
+		return trace.SpanContext{}, err
+	}
+
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return trace.SpanContext{}, fmt.Errorf("%w: %s", ErrInvalidTraceFlag, parts[3])
+	}
+
+	config := trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, Remote: true}
+
+	if flags&(_flagSampled|_flagDebug) != 0 {
+		config.TraceFlags = trace.FlagsSampled
+	}
+
+	if flags&_flagDebug != 0 && forcekeep.Enabled() {
+		config.TraceState, _ = config.TraceState.Insert(forcekeep.TraceStateKey, forcekeep.TraceStateValue)
+	}
+
+	return trace.NewSpanContext(config), nil
+}
+
+// formatTraceID formats 64-bit trace ids of old jaeger clients without the zero high part.
+func formatTraceID(traceID trace.TraceID) string {
+	if traceID[0]|traceID[1]|traceID[2]|traceID[3]|traceID[4]|traceID[5]|traceID[6]|traceID[7] == 0 {
+		return hex.EncodeToString(traceID[8:])
+	}
+
+	return traceID.String()
+}
+
+// parseTraceID parses 64 or 128-bit trace id, leading zeros can be omitted.
+func parseTraceID(value string) (trace.TraceID, error) {
+	var traceID trace.TraceID
+
+	if value == "" || len(value) > _traceIDHexLen {
+		return traceID, fmt.Errorf("%w: %s", ErrInvalidTraceID, value)
+	}
+
+	b, err := hex.DecodeString(strings.Repeat("0", _traceIDHexLen-len(value)) + value)
+	if err != nil {
+		return traceID, fmt.Errorf("%w: %s", ErrInvalidTraceID, value)
+	}
+
+	copy(traceID[:], b)
+
+	if !traceID.IsValid() {
+		return traceID, fmt.Errorf("%w: %s", ErrInvalidTraceID, value)
+	}
+
+	return traceID, nil
+}
+
+// parseSpanID parses 64-bit span id, leading zeros can be omitted.
+func parseSpanID(value string) (trace.SpanID, error) {
+	var spanID trace.SpanID
+
+	if value == "" || len(value) > _spanIDHexLen {
+		return spanID, fmt.Errorf("%w: %s", ErrInvalidSpanID, value)
+	}
+
+	b, err := hex.DecodeString(strings.Repeat("0", _spanIDHexLen-len(value)) + value)
+	if err != nil {
+		return spanID, fmt.Errorf("%w: %s", ErrInvalidSpanID, value)
+	}
+
+	copy(spanID[:], b)
+
+	if !spanID.IsValid() {
+		return spanID, fmt.Errorf("%w: %s", ErrInvalidSpanID, value)
+	}
+
+	return spanID, nil
+}
+
+// extractBaggage adds uberctx-* members to the baggage of the Context.
+func extractBaggage(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	bag := baggage.FromContext(ctx)
+
+	for _, key := range carrier.Keys() {
+		lower := strings.ToLower(key)
+		if !strings.HasPrefix(lower, BaggageHeaderPrefix) {
+			continue
+		}
+
+		value, err := url.QueryUnescape(carrier.Get(key))
+		if err != nil {
+			continue
+		}
+
+		// Values which can not be sent in the baggage header are skipped, otherwise
+		// downstream services would drop the whole header.
+		member, err := baggage.NewMember(strings.TrimPrefix(lower, BaggageHeaderPrefix), value)
+		if err != nil || member.Value() != value {
+			continue
+		}
+
+		if next, err := bag.SetMember(member); err == nil {
+			bag = next
+		}
+	}
+
+	if bag.Len() == 0 {
+		return ctx
+	}
+
+	return baggage.ContextWithBaggage(ctx, bag)
+}
@@ -0,0 +1,223 @@
+package jaegerpropagator
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/loghole/tracing/internal/forcekeep"
+)
+
+func TestParse(t *testing.T) {
+	enableForceKeep(t)
+
+	tests := []struct {
+		name        string
+		header      string
+		wantTraceID trace.TraceID
+		wantSpanID  trace.SpanID
+		wantSampled bool
+		wantDebug   bool
+		wantErr     error
+	}{
+		{
+			name:        "128-bit",
+			header:      "0102030405060708090a0b0c0d0e0f10:0102030405060708:0:1",
+			wantTraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			wantSpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+			wantSampled: true,
+		},
+		{
+			name:        "64-bit without leading zeros",
+			header:      "a1b2:c3:0:0",
+			wantTraceID: trace.TraceID{14: 0xa1, 15: 0xb2},
+			wantSpanID:  trace.SpanID{7: 0xc3},
+		},
+		{
+			name:        "debug",
+			header:      "1:2:1:2",
+			wantTraceID: trace.TraceID{15: 1},
+			wantSpanID:  trace.SpanID{7: 2},
+			wantSampled: true,
+			wantDebug:   true,
+		},
+		{
+			name:        "hex flags",
+			header:      "1:2:0:b",
+			wantTraceID: trace.TraceID{15: 1},
+			wantSpanID:  trace.SpanID{7: 2},
+			wantSampled: true,
+			wantDebug:   true,
+		},
+		{
+			name:        "url encoded",
+			header:      "1%3A2%3A0%3A1",
+			wantTraceID: trace.TraceID{15: 1},
+			wantSpanID:  trace.SpanID{7: 2},
+			wantSampled: true,
+		},
+		{
+			name:    "malformed",
+			header:  "1:2:1",
+			wantErr: ErrMalformedHeader,
+		},
+		{
+			name:    "zero trace id",
+			header:  "0:2:0:1",
+			wantErr: ErrInvalidTraceID,
+		},
+		{
+			name:    "long trace id",
+			header:  "0102030405060708090a0b0c0d0e0f1011:2:0:1",
+			wantErr: ErrInvalidTraceID,
+		},
+		{
+			name:    "invalid span id",
+			header:  "1:xyz:0:1",
+			wantErr: ErrInvalidSpanID,
+		},
+		{
+			name:    "invalid flags",
+			header:  "1:2:0:x",
+			wantErr: ErrInvalidTraceFlag,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spanCtx, err := Parse(tt.header)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTraceID, spanCtx.TraceID())
+			assert.Equal(t, tt.wantSpanID, spanCtx.SpanID())
+			assert.Equal(t, tt.wantSampled, spanCtx.IsSampled())
+			assert.Equal(t, tt.wantDebug, forcekeep.InTraceState(spanCtx.TraceState()))
+			assert.True(t, spanCtx.IsRemote())
+		})
+	}
+}
+
+func TestPropagator_Inject(t *testing.T) {
+	enableForceKeep(t)
+
+	member, err := baggage.NewMember("tenant.id", "42")
+	require.NoError(t, err)
+
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		spanCtx trace.SpanContextConfig
+		want    http.Header
+	}{
+		{
+			name:    "64-bit",
+			spanCtx: trace.SpanContextConfig{TraceID: trace.TraceID{15: 1}, SpanID: trace.SpanID{7: 2}, TraceFlags: trace.FlagsSampled},
+			want: http.Header{
+				"Uber-Trace-Id":     []string{"0000000000000001:0000000000000002:0:1"},
+				"Uberctx-Tenant.id": []string{"42"},
+			},
+		},
+		{
+			name:    "128-bit not sampled",
+			spanCtx: trace.SpanContextConfig{TraceID: trace.TraceID{0: 1, 15: 1}, SpanID: trace.SpanID{7: 2}},
+			want: http.Header{
+				"Uber-Trace-Id":     []string{"01000000000000000000000000000001:0000000000000002:0:0"},
+				"Uberctx-Tenant.id": []string{"42"},
+			},
+		},
+		{
+			name: "debug",
+			spanCtx: func() trace.SpanContextConfig {
+				state, _ := trace.TraceState{}.Insert(forcekeep.TraceStateKey, forcekeep.TraceStateValue)
+
+				return trace.SpanContextConfig{TraceID: trace.TraceID{15: 1}, SpanID: trace.SpanID{7: 2}, TraceState: state}
+			}(),
+			want: http.Header{
+				"Uber-Trace-Id":     []string{"0000000000000001:0000000000000002:0:3"},
+				"Uberctx-Tenant.id": []string{"42"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := trace.ContextWithSpanContext(baggage.ContextWithBaggage(context.Background(), bag),
+				trace.NewSpanContext(tt.spanCtx))
+
+			carrier := http.Header{}
+
+			Propagator{}.Inject(ctx, propagation.HeaderCarrier(carrier))
+
+			assert.Equal(t, tt.want, carrier)
+		})
+	}
+}
+
+func TestPropagator_Extract(t *testing.T) {
+	enableForceKeep(t)
+
+	carrier := http.Header{
+		"Uber-Trace-Id":     []string{"1:2:0:3"},
+		"Uberctx-Tenant.id": []string{"42"},
+		"Uberctx-Comment":   []string{"two%20words"},
+	}
+
+	ctx := Propagator{}.Extract(context.Background(), propagation.HeaderCarrier(carrier))
+
+	spanCtx := trace.SpanContextFromContext(ctx)
+
+	assert.Equal(t, trace.TraceID{15: 1}, spanCtx.TraceID())
+	assert.True(t, forcekeep.IsSet(ctx))
+	assert.Equal(t, "42", baggage.FromContext(ctx).Member("tenant.id").Value())
+	assert.Equal(t, 1, baggage.FromContext(ctx).Len(), "values which can not be propagated are skipped")
+
+	roundTrip := http.Header{}
+
+	Propagator{}.Inject(ctx, propagation.HeaderCarrier(roundTrip))
+
+	assert.Equal(t, "0000000000000001:0000000000000002:0:3", roundTrip.Get(TraceContextHeader))
+}
+
+func TestPropagator_RoundTrip(t *testing.T) {
+	enableForceKeep(t)
+
+	ctx := Propagator{}.Extract(context.Background(), propagation.HeaderCarrier(http.Header{
+		"Uber-Trace-Id": []string{"1:2:0:b"},
+	}))
+
+	carrier := http.Header{}
+
+	Propagator{}.Inject(ctx, propagation.HeaderCarrier(carrier))
+
+	assert.Equal(t, "0000000000000001:0000000000000002:0:3", carrier.Get(TraceContextHeader))
+}
+
+func TestPropagator_ExtractForceKeepDisabled(t *testing.T) {
+	ctx := Propagator{}.Extract(context.Background(), propagation.HeaderCarrier(http.Header{
+		"Uber-Trace-Id": []string{"1:2:0:3"},
+	}))
+
+	assert.True(t, trace.SpanContextFromContext(ctx).IsSampled())
+	assert.False(t, forcekeep.InTraceState(trace.SpanContextFromContext(ctx).TraceState()))
+}
+
+// enableForceKeep maps the debug flag to the force-keep decision for the test.
+func enableForceKeep(t *testing.T) {
+	t.Helper()
+
+	forcekeep.SetEnabled(true)
+	t.Cleanup(func() { forcekeep.SetEnabled(false) })
+}
@@ -42,6 +42,79 @@ var (
 
 	HTTPSuccessInputReqCounter = inputRequestsCounter.WithLabelValues("http", "success")
 	HTTPFailedInputReqCounter  = inputRequestsCounter.WithLabelValues("http", "failed")
+
+	sampledEvictedTracesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "sampled_evicted_traces_total",
+		Help:        "Number of incomplete traces force-decided by the tail sampler",
+		ConstLabels: nil,
+	}, []string{"reason"})
+
+	SampledEvictedTracesMaxTracesCounter = sampledEvictedTracesCounter.WithLabelValues("max_traces")
+	SampledEvictedTracesMaxAgeCounter    = sampledEvictedTracesCounter.WithLabelValues("max_age")
+
+	sampledDroppedSpansCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "sampled_dropped_spans_total",
+		Help:        "Number of spans dropped by the tail sampler limits",
+		ConstLabels: nil,
+	}, []string{"reason"})
+
+	SampledDroppedSpansMaxSpansCounter = sampledDroppedSpansCounter.WithLabelValues("max_spans")
+	SampledDroppedSpansEvictedCounter  = sampledDroppedSpansCounter.WithLabelValues("evicted")
+
+	SampledBufferedTraces = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "sampled_buffered_traces",
+		Help:        "Number of traces buffered by the tail sampler",
+		ConstLabels: nil,
+	})
+
+	SampledBufferedSpans = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "sampled_buffered_spans",
+		Help:        "Number of spans buffered by the tail sampler",
+		ConstLabels: nil,
+	})
+
+	sampledTracesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "sampled_traces_total",
+		Help:        "Number of traces decided by the tail sampler",
+		ConstLabels: nil,
+	}, []string{"decision", "reason"})
+
+	SampledKeptErrorCounter          = sampledTracesCounter.WithLabelValues("keep", "error")
+	SampledKeptLatencyCounter        = sampledTracesCounter.WithLabelValues("keep", "latency")
+	SampledKeptHeadSamplerCounter    = sampledTracesCounter.WithLabelValues("keep", "head_sampler")
+	SampledKeptPolicyCounter         = sampledTracesCounter.WithLabelValues("keep", "policy")
+	SampledKeptForceKeepCounter      = sampledTracesCounter.WithLabelValues("keep", "force_keep")
+	SampledDroppedHeadSamplerCounter = sampledTracesCounter.WithLabelValues("drop", "head_sampler")
+	SampledDroppedPolicyCounter      = sampledTracesCounter.WithLabelValues("drop", "policy")
+	SampledDroppedRateLimitCounter   = sampledTracesCounter.WithLabelValues("drop", "rate_limit")
+
+	sampledFlushDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "sampled_flush_duration_seconds",
+		Help:        "Duration of the tail sampler buffer flushes",
+		ConstLabels: nil,
+		Buckets:     prometheus.DefBuckets,
+	}, []string{"reason"})
+
+	SampledFlushDurationForceFlush = sampledFlushDuration.WithLabelValues("force_flush")
+	SampledFlushDurationMaxAge     = sampledFlushDuration.WithLabelValues("max_age")
+
+	SpoolQueueBatches = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "spool_queue_batches",
+		Help:        "Number of span batches waiting in the disk spool",
+		ConstLabels: nil,
+	}, []string{"dir"})
+
+	SpoolQueueBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "spool_queue_bytes",
+		Help:        "Size of span batches waiting in the disk spool",
+		ConstLabels: nil,
+	}, []string{"dir"})
+
+	SpoolDroppedBatchesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "spool_dropped_batches_total",
+		Help:        "Number of span batches dropped from the disk spool",
+		ConstLabels: nil,
+	}, []string{"dir", "reason"})
 )
 
 func Register() error {
@@ -57,5 +130,41 @@ func Register() error {
 		return fmt.Errorf("register input requests counter: %w", err)
 	}
 
+	if err := prometheus.Register(sampledEvictedTracesCounter); err != nil {
+		return fmt.Errorf("register sampled evicted traces counter: %w", err)
+	}
+
+	if err := prometheus.Register(sampledDroppedSpansCounter); err != nil {
+		return fmt.Errorf("register sampled dropped spans counter: %w", err)
+	}
+
+	if err := prometheus.Register(SampledBufferedTraces); err != nil {
+		return fmt.Errorf("register sampled buffered traces gauge: %w", err)
+	}
+
+	if err := prometheus.Register(SampledBufferedSpans); err != nil {
+		return fmt.Errorf("register sampled buffered spans gauge: %w", err)
+	}
+
+	if err := prometheus.Register(sampledTracesCounter); err != nil {
+		return fmt.Errorf("register sampled traces counter: %w", err)
+	}
+
+	if err := prometheus.Register(sampledFlushDuration); err != nil {
+		return fmt.Errorf("register sampled flush duration histogram: %w", err)
+	}
+
+	if err := prometheus.Register(SpoolQueueBatches); err != nil {
+		return fmt.Errorf("register spool queue batches gauge: %w", err)
+	}
+
+	if err := prometheus.Register(SpoolQueueBytes); err != nil {
+		return fmt.Errorf("register spool queue bytes gauge: %w", err)
+	}
+
+	if err := prometheus.Register(SpoolDroppedBatchesCounter); err != nil {
+		return fmt.Errorf("register spool dropped batches counter: %w", err)
+	}
+
 	return nil
 }
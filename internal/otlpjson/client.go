@@ -0,0 +1,185 @@
+package otlpjson
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const _defaultTimeout = 10 * time.Second
+
+var _ otlptrace.Client = new(Client)
+
+// Config configures Client.
+type Config struct {
+	URL       string
+	Headers   map[string]string
+	Gzip      bool
+	Timeout   time.Duration
+	TLSConfig *tls.Config
+}
+
+// Client sends spans to the OTLP/HTTP endpoint with JSON encoding.
+type Client struct {
+	config Config
+	client *http.Client
+}
+
+func NewClient(config Config) *Client {
+	if config.Timeout <= 0 {
+		config.Timeout = _defaultTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // default transport.
+	transport.TLSClientConfig = config.TLSConfig
+
+	return &Client{
+		config: config,
+		client: &http.Client{Transport: transport, Timeout: config.Timeout},
+	}
+}
+
+func (c *Client) Start(_ context.Context) error {
+	return nil
+}
+
+func (c *Client) Stop(_ context.Context) error {
+	c.client.CloseIdleConnections()
+
+	return nil
+}
+
+func (c *Client) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	body, err := Marshal(&collectortracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return err
+	}
+
+	if c.config.Gzip {
+		if body, err = compress(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+
+	for key, value := range c.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if c.config.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected response status: %s", resp.Status) //nolint:goerr113 // dynamic error.
+	}
+
+	return nil
+}
+
+// Marshal encodes request to the OTLP/JSON format. In contrast to the protojson
+// encoding trace and span identifiers are encoded as hex strings and enums as numbers.
+func Marshal(req *collectortracepb.ExportTraceServiceRequest) ([]byte, error) {
+	data, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	var obj interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("decode request: %w", err)
+	}
+
+	if err := hexIdentifiers(obj); err != nil {
+		return nil, err
+	}
+
+	data, err = json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+
+	return data, nil
+}
+
+func hexIdentifiers(obj interface{}) error {
+	switch val := obj.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			switch key {
+			case "traceId", "spanId", "parentSpanId":
+				str, ok := item.(string)
+				if !ok {
+					continue
+				}
+
+				id, err := base64.StdEncoding.DecodeString(str)
+				if err != nil {
+					return fmt.Errorf("decode %s: %w", key, err)
+				}
+
+				val[key] = hex.EncodeToString(id)
+			default:
+				if err := hexIdentifiers(item); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if err := hexIdentifiers(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer := gzip.NewWriter(&buf)
+
+	if _, err := writer.Write(data); err != nil {
+		return nil, fmt.Errorf("gzip write: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("gzip close: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,297 @@
+package spancodec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ReadOnly restores finished span from the JSON representation.
+// Numbers should be decoded with json.Decoder.UseNumber to keep int64 values.
+func (s *Span) ReadOnly() (tracesdk.ReadOnlySpan, error) {
+	spanCtx, err := spanContext(s.TraceID, s.SpanID, s.TraceState, s.Sampled, s.Remote)
+	if err != nil {
+		return nil, err
+	}
+
+	stub := tracetest.SpanStub{
+		Name:              s.Name,
+		SpanContext:       spanCtx,
+		SpanKind:          spanKind(s.Kind),
+		StartTime:         s.StartTime,
+		EndTime:           s.EndTime,
+		Status:            tracesdk.Status{Code: statusCode(s.Status.Code), Description: s.Status.Description},
+		DroppedAttributes: s.DroppedAttributes,
+		DroppedEvents:     s.DroppedEvents,
+		DroppedLinks:      s.DroppedLinks,
+		ChildSpanCount:    s.ChildSpanCount,
+		InstrumentationLibrary: instrumentation.Scope{
+			Name:      s.Scope.Name,
+			Version:   s.Scope.Version,
+			SchemaURL: s.Scope.SchemaURL,
+		},
+	}
+
+	if s.ParentSpanID != "" {
+		if stub.Parent, err = spanContext(s.TraceID, s.ParentSpanID, "", s.Sampled, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if stub.Attributes, err = ToAttributes(s.Attributes); err != nil {
+		return nil, err
+	}
+
+	for _, event := range s.Events {
+		attributes, err := ToAttributes(event.Attributes)
+		if err != nil {
+			return nil, err
+		}
+
+		stub.Events = append(stub.Events, tracesdk.Event{
+			Name:                  event.Name,
+			Time:                  event.Time,
+			Attributes:            attributes,
+			DroppedAttributeCount: event.DroppedAttributes,
+		})
+	}
+
+	for _, link := range s.Links {
+		linkCtx, err := spanContext(link.TraceID, link.SpanID, link.TraceState, false, false)
+		if err != nil {
+			return nil, err
+		}
+
+		attributes, err := ToAttributes(link.Attributes)
+		if err != nil {
+			return nil, err
+		}
+
+		stub.Links = append(stub.Links, tracesdk.Link{
+			SpanContext:           linkCtx,
+			Attributes:            attributes,
+			DroppedAttributeCount: link.DroppedAttributes,
+		})
+	}
+
+	resourceAttributes, err := ToAttributes(s.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	stub.Resource = resource.NewSchemaless(resourceAttributes...)
+
+	return stub.Snapshot(), nil
+}
+
+// ToAttributes restores attributes from the JSON representation.
+func ToAttributes(attributes []Attribute) ([]attribute.KeyValue, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+
+	result := make([]attribute.KeyValue, 0, len(attributes))
+
+	for _, attr := range attributes {
+		value, err := attr.value()
+		if err != nil {
+			return nil, fmt.Errorf("attribute '%s': %w", attr.Key, err)
+		}
+
+		result = append(result, attribute.KeyValue{Key: attribute.Key(attr.Key), Value: value})
+	}
+
+	return result, nil
+}
+
+func (a *Attribute) value() (attribute.Value, error) {
+	switch a.Type {
+	case attribute.BOOL.String():
+		value, ok := a.Value.(bool)
+		if !ok {
+			return attribute.Value{}, fmt.Errorf("unexpected %s value %v", a.Type, a.Value)
+		}
+
+		return attribute.BoolValue(value), nil
+	case attribute.INT64.String():
+		value, err := toInt64(a.Value)
+		if err != nil {
+			return attribute.Value{}, err
+		}
+
+		return attribute.Int64Value(value), nil
+	case attribute.FLOAT64.String():
+		value, err := toFloat64(a.Value)
+		if err != nil {
+			return attribute.Value{}, err
+		}
+
+		return attribute.Float64Value(value), nil
+	case attribute.STRING.String():
+		value, ok := a.Value.(string)
+		if !ok {
+			return attribute.Value{}, fmt.Errorf("unexpected %s value %v", a.Type, a.Value)
+		}
+
+		return attribute.StringValue(value), nil
+	case attribute.BOOLSLICE.String(), attribute.INT64SLICE.String(),
+		attribute.FLOAT64SLICE.String(), attribute.STRINGSLICE.String():
+		return a.sliceValue()
+	default:
+		return attribute.Value{}, fmt.Errorf("unknown type '%s'", a.Type)
+	}
+}
+
+func (a *Attribute) sliceValue() (attribute.Value, error) {
+	items, ok := a.Value.([]interface{})
+	if !ok && a.Value != nil {
+		return attribute.Value{}, fmt.Errorf("unexpected %s value %v", a.Type, a.Value)
+	}
+
+	var (
+		bools   = make([]bool, 0, len(items))
+		ints    = make([]int64, 0, len(items))
+		floats  = make([]float64, 0, len(items))
+		strings = make([]string, 0, len(items))
+	)
+
+	for _, item := range items {
+		var err error
+
+		switch a.Type {
+		case attribute.BOOLSLICE.String():
+			value, ok := item.(bool)
+			if !ok {
+				err = fmt.Errorf("unexpected %s item %v", a.Type, item)
+			}
+
+			bools = append(bools, value)
+		case attribute.INT64SLICE.String():
+			var value int64
+
+			value, err = toInt64(item)
+			ints = append(ints, value)
+		case attribute.FLOAT64SLICE.String():
+			var value float64
+
+			value, err = toFloat64(item)
+			floats = append(floats, value)
+		default:
+			value, ok := item.(string)
+			if !ok {
+				err = fmt.Errorf("unexpected %s item %v", a.Type, item)
+			}
+
+			strings = append(strings, value)
+		}
+
+		if err != nil {
+			return attribute.Value{}, err
+		}
+	}
+
+	switch a.Type {
+	case attribute.BOOLSLICE.String():
+		return attribute.BoolSliceValue(bools), nil
+	case attribute.INT64SLICE.String():
+		return attribute.Int64SliceValue(ints), nil
+	case attribute.FLOAT64SLICE.String():
+		return attribute.Float64SliceValue(floats), nil
+	default:
+		return attribute.StringSliceValue(strings), nil
+	}
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		result, err := v.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("parse int: %w", err)
+		}
+
+		return result, nil
+	case float64:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("unexpected int value %v", value)
+	}
+}
+
+func toFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		result, err := v.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("parse float: %w", err)
+		}
+
+		return result, nil
+	case float64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("unexpected float value %v", value)
+	}
+}
+
+func spanContext(traceID, spanID, traceState string, sampled, remote bool) (trace.SpanContext, error) {
+	var (
+		config = trace.SpanContextConfig{Remote: remote}
+		err    error
+	)
+
+	if config.TraceID, err = trace.TraceIDFromHex(traceID); err != nil {
+		return trace.SpanContext{}, fmt.Errorf("parse trace id '%s': %w", traceID, err)
+	}
+
+	if config.SpanID, err = trace.SpanIDFromHex(spanID); err != nil {
+		return trace.SpanContext{}, fmt.Errorf("parse span id '%s': %w", spanID, err)
+	}
+
+	if traceState != "" {
+		if config.TraceState, err = trace.ParseTraceState(traceState); err != nil {
+			return trace.SpanContext{}, fmt.Errorf("parse trace state: %w", err)
+		}
+	}
+
+	if sampled {
+		config.TraceFlags = trace.FlagsSampled
+	}
+
+	return trace.NewSpanContext(config), nil
+}
+
+func spanKind(kind string) trace.SpanKind {
+	for _, value := range []trace.SpanKind{
+		trace.SpanKindInternal,
+		trace.SpanKindServer,
+		trace.SpanKindClient,
+		trace.SpanKindProducer,
+		trace.SpanKindConsumer,
+	} {
+		if value.String() == kind {
+			return value
+		}
+	}
+
+	return trace.SpanKindUnspecified
+}
+
+func statusCode(code string) codes.Code {
+	for _, value := range []codes.Code{codes.Error, codes.Ok} {
+		if value.String() == code {
+			return value
+		}
+	}
+
+	return codes.Unset
+}
@@ -0,0 +1,81 @@
+package spancodec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpan_ReadOnly(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := tracesdk.NewTracerProvider(
+		tracesdk.WithSpanProcessor(recorder),
+		tracesdk.WithResource(resource.NewSchemaless(attribute.String("service.name", "test"))),
+	)
+
+	ctx, parent := provider.Tracer("scope", trace.WithInstrumentationVersion("v1")).Start(context.Background(), "parent")
+
+	_, span := provider.Tracer("scope").Start(ctx, "child",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithLinks(trace.Link{SpanContext: parent.SpanContext(), Attributes: []attribute.KeyValue{attribute.Bool("b", true)}}),
+		trace.WithAttributes(
+			attribute.Int64("int", 1<<60),
+			attribute.Float64("float", 1.5),
+			attribute.String("string", "value"),
+			attribute.BoolSlice("bools", []bool{true, false}),
+			attribute.Int64Slice("ints", []int64{1, 2}),
+			attribute.Float64Slice("floats", []float64{0.5}),
+			attribute.StringSlice("strings", []string{"a", "b"}),
+		),
+	)
+	span.AddEvent("event", trace.WithAttributes(attribute.Int("n", 1)))
+	span.SetStatus(codes.Error, "failed")
+	span.End()
+	parent.End()
+
+	want := recorder.Ended()[0]
+
+	data, err := json.Marshal(FromReadOnly(want))
+	require.NoError(t, err)
+
+	var decoded Span
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&decoded))
+
+	got, err := decoded.ReadOnly()
+	require.NoError(t, err)
+
+	assert.Equal(t, want.Name(), got.Name())
+	assert.True(t, want.SpanContext().Equal(got.SpanContext()))
+	assert.Equal(t, want.Parent().SpanID(), got.Parent().SpanID())
+	assert.Equal(t, want.SpanKind(), got.SpanKind())
+	assert.True(t, want.StartTime().Equal(got.StartTime()))
+	assert.True(t, want.EndTime().Equal(got.EndTime()))
+	assert.Equal(t, want.Status(), got.Status())
+	assert.Equal(t, want.Attributes(), got.Attributes())
+	assert.Equal(t, want.Events()[0].Attributes, got.Events()[0].Attributes)
+	assert.Equal(t, want.Links()[0].SpanContext.SpanID(), got.Links()[0].SpanContext.SpanID())
+	assert.Equal(t, want.Links()[0].Attributes, got.Links()[0].Attributes)
+	assert.Equal(t, want.Resource().Attributes(), got.Resource().Attributes())
+	assert.Equal(t, want.InstrumentationScope(), got.InstrumentationScope())
+}
+
+func TestToAttributes_Error(t *testing.T) {
+	_, err := ToAttributes([]Attribute{{Key: "k", Type: "INT64", Value: "x"}})
+	assert.EqualError(t, err, "attribute 'k': unexpected int value x")
+
+	_, err = ToAttributes([]Attribute{{Key: "k", Type: "MAP", Value: nil}})
+	assert.EqualError(t, err, "attribute 'k': unknown type 'MAP'")
+}
@@ -0,0 +1,148 @@
+package spancodec
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Span is JSON representation of tracesdk.ReadOnlySpan.
+type Span struct {
+	TraceID      string      `json:"trace_id"`
+	SpanID       string      `json:"span_id"`
+	ParentSpanID string      `json:"parent_span_id,omitempty"`
+	TraceState   string      `json:"trace_state,omitempty"`
+	Sampled      bool        `json:"sampled"`
+	Remote       bool        `json:"remote,omitempty"`
+	Name         string      `json:"name"`
+	Kind         string      `json:"kind"`
+	StartTime    time.Time   `json:"start_time"`
+	EndTime      time.Time   `json:"end_time"`
+	Status       Status      `json:"status"`
+	Attributes   []Attribute `json:"attributes,omitempty"`
+	Events       []Event     `json:"events,omitempty"`
+	Links        []Link      `json:"links,omitempty"`
+	Resource     []Attribute `json:"resource,omitempty"`
+	Scope        Scope       `json:"scope"`
+
+	DroppedAttributes int `json:"dropped_attributes,omitempty"`
+	DroppedEvents     int `json:"dropped_events,omitempty"`
+	DroppedLinks      int `json:"dropped_links,omitempty"`
+	ChildSpanCount    int `json:"child_span_count,omitempty"`
+}
+
+type Status struct {
+	Code        string `json:"code"`
+	Description string `json:"description,omitempty"`
+}
+
+type Event struct {
+	Name       string      `json:"name"`
+	Time       time.Time   `json:"time"`
+	Attributes []Attribute `json:"attributes,omitempty"`
+
+	DroppedAttributes int `json:"dropped_attributes,omitempty"`
+}
+
+type Link struct {
+	TraceID    string      `json:"trace_id"`
+	SpanID     string      `json:"span_id"`
+	TraceState string      `json:"trace_state,omitempty"`
+	Attributes []Attribute `json:"attributes,omitempty"`
+
+	DroppedAttributes int `json:"dropped_attributes,omitempty"`
+}
+
+type Scope struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	SchemaURL string `json:"schema_url,omitempty"`
+}
+
+// Attribute keeps value type to restore attribute.KeyValue without losses.
+type Attribute struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// FromReadOnly converts finished span to the JSON representation.
+func FromReadOnly(span tracesdk.ReadOnlySpan) *Span {
+	var (
+		spanCtx = span.SpanContext()
+		result  = &Span{
+			TraceID:           spanCtx.TraceID().String(),
+			SpanID:            spanCtx.SpanID().String(),
+			TraceState:        spanCtx.TraceState().String(),
+			Sampled:           spanCtx.IsSampled(),
+			Remote:            spanCtx.IsRemote(),
+			Name:              span.Name(),
+			Kind:              span.SpanKind().String(),
+			StartTime:         span.StartTime(),
+			EndTime:           span.EndTime(),
+			Status:            Status{Code: span.Status().Code.String(), Description: span.Status().Description},
+			Attributes:        FromAttributes(span.Attributes()),
+			DroppedAttributes: span.DroppedAttributes(),
+			DroppedEvents:     span.DroppedEvents(),
+			DroppedLinks:      span.DroppedLinks(),
+			ChildSpanCount:    span.ChildSpanCount(),
+		}
+	)
+
+	if parent := span.Parent(); parent.HasSpanID() {
+		result.ParentSpanID = parent.SpanID().String()
+	}
+
+	for _, event := range span.Events() {
+		result.Events = append(result.Events, Event{
+			Name:              event.Name,
+			Time:              event.Time,
+			Attributes:        FromAttributes(event.Attributes),
+			DroppedAttributes: event.DroppedAttributeCount,
+		})
+	}
+
+	for _, link := range span.Links() {
+		result.Links = append(result.Links, Link{
+			TraceID:           link.SpanContext.TraceID().String(),
+			SpanID:            link.SpanContext.SpanID().String(),
+			TraceState:        link.SpanContext.TraceState().String(),
+			Attributes:        FromAttributes(link.Attributes),
+			DroppedAttributes: link.DroppedAttributeCount,
+		})
+	}
+
+	if res := span.Resource(); res != nil {
+		result.Resource = FromAttributes(res.Attributes())
+	}
+
+	scope := span.InstrumentationScope()
+	result.Scope = Scope{Name: scope.Name, Version: scope.Version, SchemaURL: scope.SchemaURL}
+
+	return result
+}
+
+// Duration returns span duration.
+func (s *Span) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// FromAttributes converts attributes to the JSON representation.
+func FromAttributes(attributes []attribute.KeyValue) []Attribute {
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	result := make([]Attribute, 0, len(attributes))
+
+	for _, attr := range attributes {
+		result = append(result, Attribute{
+			Key:   string(attr.Key),
+			Type:  attr.Value.Type().String(),
+			Value: attr.Value.AsInterface(),
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,466 @@
+// Package spool contains span exporter which keeps batches on disk while the downstream exporter is unavailable.
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/loghole/tracing/internal/metrics"
+	"github.com/loghole/tracing/internal/spancodec"
+)
+
+const (
+	_fileExt = ".spool"
+	_tmpExt  = ".tmp"
+
+	_defaultMaxSize    = 100 << 20
+	_defaultMinBackoff = time.Second
+	_defaultMaxBackoff = time.Minute
+	_defaultTimeout    = 10 * time.Second
+)
+
+// Reasons of dropped batches.
+const (
+	_reasonSize    = "size"
+	_reasonAge     = "age"
+	_reasonCorrupt = "corrupt"
+	_reasonMissing = "missing"
+)
+
+var ErrEmptyDir = errors.New("empty spool dir")
+
+// Config configures the spool.
+type Config struct {
+	// Dir keeps spooled batches, it is created if not exists.
+	Dir string
+	// MaxSize is the max size of spooled batches in bytes, the oldest batches are dropped after it. 100MiB by default.
+	MaxSize int64
+	// MaxAge drops batches older than the value, zero means no limit.
+	MaxAge time.Duration
+	// MinBackoff and MaxBackoff limit delay between replay attempts, 1s and 1m by default.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Timeout limits export of a replayed batch, new batches wait for it. 10s by default.
+	Timeout time.Duration
+}
+
+type entry struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+// Exporter sends spans to the downstream exporter. Batches which failed to export are written
+// to the spool dir and replayed in order in background. While the spool is not empty, new batches
+// are appended to it so the order of batches is kept.
+type Exporter struct {
+	config Config
+	next   tracesdk.SpanExporter
+
+	// sendMu serializes sends to the downstream exporter with the spool check,
+	// so a new batch never overtakes a batch which is spooled or replayed.
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	entries []entry
+	size    int64
+	seq     uint64
+
+	wakeup chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// New returns spool exporter, batches spooled by a previous process are replayed as well.
+func New(next tracesdk.SpanExporter, config Config) (*Exporter, error) {
+	if config.Dir == "" {
+		return nil, ErrEmptyDir
+	}
+
+	if config.MaxSize <= 0 {
+		config.MaxSize = _defaultMaxSize
+	}
+
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = _defaultMinBackoff
+	}
+
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = _defaultMaxBackoff
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = _defaultTimeout
+	}
+
+	if err := os.MkdirAll(config.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create dir: %w", err)
+	}
+
+	exporter := &Exporter{
+		config: config,
+		next:   next,
+		wakeup: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+
+	if err := exporter.load(); err != nil {
+		return nil, err
+	}
+
+	exporter.wg.Add(1)
+
+	go exporter.replay()
+
+	return exporter, nil
+}
+
+// ExportSpans exports spans directly if the spool is empty and writes them to the spool otherwise.
+// Error is returned only if spans were neither exported nor spooled.
+func (e *Exporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	e.sendMu.Lock()
+	defer e.sendMu.Unlock()
+
+	if e.Pending() == 0 {
+		if err := e.next.ExportSpans(ctx, spans); err == nil {
+			return nil
+		}
+	}
+
+	if err := e.write(spans); err != nil {
+		return fmt.Errorf("spool spans: %w", err)
+	}
+
+	select {
+	case e.wakeup <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Shutdown stops replaying and shuts down the downstream exporter, pending batches are kept on disk.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.once.Do(func() { close(e.done) })
+	e.wg.Wait()
+
+	return e.next.Shutdown(ctx)
+}
+
+// Pending returns number of batches in the spool.
+func (e *Exporter) Pending() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return len(e.entries)
+}
+
+func (e *Exporter) load() error {
+	files, err := os.ReadDir(e.config.Dir)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, file := range files {
+		// Temporary file is left if the process was stopped during the write.
+		if strings.HasSuffix(file.Name(), _fileExt+_tmpExt) {
+			_ = os.Remove(filepath.Join(e.config.Dir, file.Name()))
+
+			continue
+		}
+
+		seq, ok := parseName(file.Name())
+		if !ok || file.IsDir() {
+			continue
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		e.entries = append(e.entries, entry{name: file.Name(), size: info.Size(), modTime: info.ModTime()})
+		e.size += info.Size()
+
+		if seq > e.seq {
+			e.seq = seq
+		}
+	}
+
+	sort.Slice(e.entries, func(i, j int) bool { return e.entries[i].name < e.entries[j].name })
+
+	e.enforceLimits(time.Now())
+
+	return nil
+}
+
+func (e *Exporter) write(spans []tracesdk.ReadOnlySpan) error {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+
+	for _, span := range spans {
+		if err := encoder.Encode(spancodec.FromReadOnly(span)); err != nil {
+			return fmt.Errorf("encode span: %w", err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.seq++
+
+	name := fmt.Sprintf("%020d%s", e.seq, _fileExt)
+
+	// Write to temporary file first so the replay never reads partially written batch.
+	tmp := filepath.Join(e.config.Dir, name+_tmpExt)
+
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(e.config.Dir, name)); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("rename file: %w", err)
+	}
+
+	e.entries = append(e.entries, entry{name: name, size: int64(buf.Len()), modTime: time.Now()})
+	e.size += int64(buf.Len())
+
+	e.enforceLimits(time.Now())
+
+	return nil
+}
+
+// enforceLimits drops the oldest batches which exceed the size or the age limit, must be called under the lock.
+func (e *Exporter) enforceLimits(now time.Time) {
+	for len(e.entries) > 0 {
+		oldest := e.entries[0]
+
+		switch {
+		case e.size > e.config.MaxSize:
+			e.dropLocked(_reasonSize)
+		case e.config.MaxAge > 0 && now.Sub(oldest.modTime) > e.config.MaxAge:
+			e.dropLocked(_reasonAge)
+		default:
+			e.updateMetrics()
+
+			return
+		}
+	}
+
+	e.updateMetrics()
+}
+
+// dropLocked removes the oldest batch, must be called under the lock.
+func (e *Exporter) dropLocked(reason string) {
+	oldest := e.entries[0]
+
+	_ = os.Remove(filepath.Join(e.config.Dir, oldest.name))
+
+	e.entries = e.entries[1:]
+	e.size -= oldest.size
+
+	if reason != "" {
+		metrics.SpoolDroppedBatchesCounter.WithLabelValues(e.config.Dir, reason).Inc()
+	}
+}
+
+func (e *Exporter) updateMetrics() {
+	metrics.SpoolQueueBatches.WithLabelValues(e.config.Dir).Set(float64(len(e.entries)))
+	metrics.SpoolQueueBytes.WithLabelValues(e.config.Dir).Set(float64(e.size))
+}
+
+func (e *Exporter) replay() {
+	defer e.wg.Done()
+
+	backoff := e.config.MinBackoff
+
+	for {
+		var delay time.Duration
+
+		sent, err := e.replayOldest()
+
+		switch {
+		case err != nil:
+			delay, backoff = backoff, backoff*2
+
+			if backoff > e.config.MaxBackoff {
+				backoff = e.config.MaxBackoff
+			}
+		case sent:
+			backoff = e.config.MinBackoff
+
+			select {
+			case <-e.done:
+				return
+			default:
+				continue
+			}
+		default:
+			backoff = e.config.MinBackoff
+		}
+
+		if !e.wait(delay) {
+			return
+		}
+	}
+}
+
+// wait waits for the delay or for the next spooled batch if delay is zero, returns false on shutdown.
+func (e *Exporter) wait(delay time.Duration) bool {
+	if delay == 0 {
+		select {
+		case <-e.done:
+			return false
+		case <-e.wakeup:
+			return true
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-e.done:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// replayOldest sends the oldest batch to the downstream exporter and removes it on success.
+func (e *Exporter) replayOldest() (bool, error) {
+	e.sendMu.Lock()
+	defer e.sendMu.Unlock()
+
+	e.mu.Lock()
+
+	e.enforceLimits(time.Now())
+
+	if len(e.entries) == 0 {
+		e.mu.Unlock()
+
+		return false, nil
+	}
+
+	oldest := e.entries[0]
+
+	e.mu.Unlock()
+
+	spans, err := e.read(oldest.name)
+	if err != nil {
+		// File could be deleted externally, the entry is dropped so the spool is not stuck on it.
+		reason := _reasonCorrupt
+		if errors.Is(err, os.ErrNotExist) {
+			reason = _reasonMissing
+		}
+
+		e.remove(oldest.name, reason)
+
+		return true, nil //nolint:nilerr // unreadable batch is dropped.
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.Timeout)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-e.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := e.next.ExportSpans(ctx, spans); err != nil {
+		return false, fmt.Errorf("export spans: %w", err)
+	}
+
+	e.remove(oldest.name, "")
+
+	return true, nil
+}
+
+func (e *Exporter) read(name string) ([]tracesdk.ReadOnlySpan, error) {
+	data, err := os.ReadFile(filepath.Join(e.config.Dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var (
+		spans   []tracesdk.ReadOnlySpan
+		scanner = bufio.NewScanner(bytes.NewReader(data))
+	)
+
+	scanner.Buffer(nil, len(data)+1)
+
+	for scanner.Scan() {
+		var span spancodec.Span
+
+		decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&span); err != nil {
+			return nil, fmt.Errorf("decode span: %w", err)
+		}
+
+		readOnly, err := span.ReadOnly()
+		if err != nil {
+			return nil, fmt.Errorf("restore span: %w", err)
+		}
+
+		spans = append(spans, readOnly)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan file: %w", err)
+	}
+
+	return spans, nil
+}
+
+// remove drops the batch if it is still the oldest one, it could be dropped by the limits during the export.
+func (e *Exporter) remove(name, reason string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.entries) > 0 && e.entries[0].name == name {
+		e.dropLocked(reason)
+		e.updateMetrics()
+	}
+}
+
+func parseName(name string) (uint64, bool) {
+	if !strings.HasSuffix(name, _fileExt) {
+		return 0, false
+	}
+
+	seq, err := strconv.ParseUint(strings.TrimSuffix(name, _fileExt), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return seq, true
+}
@@ -0,0 +1,294 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+// downstream fails while it is down and records names of exported spans.
+type downstream struct {
+	mu    sync.Mutex
+	down  bool
+	names []string
+}
+
+func (d *downstream) ExportSpans(_ context.Context, spans []tracesdk.ReadOnlySpan) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.down {
+		return errUnavailable
+	}
+
+	for _, span := range spans {
+		d.names = append(d.names, span.Name())
+	}
+
+	return nil
+}
+
+func (d *downstream) Shutdown(context.Context) error { return nil }
+
+func (d *downstream) setDown(down bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.down = down
+}
+
+func (d *downstream) exported() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]string(nil), d.names...)
+}
+
+func spans(names ...string) []tracesdk.ReadOnlySpan {
+	stubs := make(tracetest.SpanStubs, 0, len(names))
+
+	for idx, name := range names {
+		spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{1},
+			SpanID:     trace.SpanID{byte(idx + 1)},
+			TraceFlags: trace.FlagsSampled,
+		})
+
+		stubs = append(stubs, tracetest.SpanStub{
+			Name:        name,
+			SpanContext: spanCtx,
+			StartTime:   time.Now(),
+			EndTime:     time.Now(),
+		})
+	}
+
+	return stubs.Snapshots()
+}
+
+func TestExporter_Replay(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		next = &downstream{down: true}
+	)
+
+	exporter, err := New(next, Config{Dir: dir, MinBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond})
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("1", "2")))
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("3")))
+	assert.Equal(t, 2, exporter.Pending())
+	assert.Empty(t, next.exported())
+
+	next.setDown(false)
+
+	// Spool is not empty, so the batch is appended to keep the order.
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("4")))
+
+	assert.Eventually(t, func() bool { return exporter.Pending() == 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, next.exported())
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("5")))
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, next.exported())
+
+	require.NoError(t, exporter.Shutdown(context.Background()))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+// slowFailure fails the first export after it is released.
+type slowFailure struct {
+	downstream
+
+	started chan struct{}
+	release chan struct{}
+	failed  atomic.Bool
+}
+
+func (d *slowFailure) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	if d.failed.CompareAndSwap(false, true) {
+		close(d.started)
+		<-d.release
+
+		return errUnavailable
+	}
+
+	return d.downstream.ExportSpans(ctx, spans)
+}
+
+func TestExporter_ConcurrentExport(t *testing.T) {
+	next := &slowFailure{started: make(chan struct{}), release: make(chan struct{})}
+
+	exporter, err := New(next, Config{Dir: t.TempDir(), MinBackoff: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	defer exporter.Shutdown(context.Background()) //nolint:errcheck // test.
+
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		assert.NoError(t, exporter.ExportSpans(context.Background(), spans("1")))
+	}()
+
+	<-next.started
+
+	go func() {
+		defer wg.Done()
+
+		assert.NoError(t, exporter.ExportSpans(context.Background(), spans("2")))
+	}()
+
+	// Let the second batch reach the spool check while the first one is being sent.
+	time.Sleep(20 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+
+	assert.Eventually(t, func() bool { return exporter.Pending() == 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"1", "2"}, next.exported())
+}
+
+func TestExporter_MissingFile(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		next = &downstream{down: true}
+	)
+
+	exporter, err := New(next, Config{Dir: dir, MinBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond})
+	require.NoError(t, err)
+
+	defer exporter.Shutdown(context.Background()) //nolint:errcheck // test.
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("1")))
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("2")))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.NoError(t, os.Remove(filepath.Join(dir, files[0].Name())))
+
+	next.setDown(false)
+
+	assert.Eventually(t, func() bool { return exporter.Pending() == 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"2"}, next.exported())
+}
+
+// hung blocks exports until the context is done.
+type hung struct {
+	calls atomic.Int32
+}
+
+func (d *hung) ExportSpans(ctx context.Context, _ []tracesdk.ReadOnlySpan) error {
+	d.calls.Add(1)
+
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+func (d *hung) Shutdown(context.Context) error { return nil }
+
+func TestExporter_ReplayTimeout(t *testing.T) {
+	next := &hung{}
+
+	exporter, err := New(next, Config{Dir: t.TempDir(), MinBackoff: time.Millisecond, Timeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+
+	defer exporter.Shutdown(context.Background()) //nolint:errcheck // test.
+
+	export := func(name string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		return exporter.ExportSpans(ctx, spans(name))
+	}
+
+	require.NoError(t, export("1"))
+
+	// Wait for the replay of the spooled batch.
+	require.Eventually(t, func() bool { return next.calls.Load() == 2 }, time.Second, time.Millisecond)
+
+	done := make(chan error, 1)
+
+	go func() { done <- export("2") }()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("export is blocked by the replay")
+	}
+
+	assert.Equal(t, 2, exporter.Pending())
+}
+
+func TestExporter_Persistent(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		next = &downstream{down: true}
+	)
+
+	exporter, err := New(next, Config{Dir: dir})
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("1")))
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("2")))
+	require.NoError(t, exporter.Shutdown(context.Background()))
+
+	next.setDown(false)
+
+	exporter, err = New(next, Config{Dir: dir})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return exporter.Pending() == 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"1", "2"}, next.exported())
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("3")))
+	require.NoError(t, exporter.Shutdown(context.Background()))
+	assert.Equal(t, []string{"1", "2", "3"}, next.exported())
+}
+
+func TestExporter_Limits(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		next = &downstream{down: true}
+	)
+
+	exporter, err := New(next, Config{Dir: dir, MaxSize: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("1")))
+	assert.Equal(t, 0, exporter.Pending())
+	require.NoError(t, exporter.Shutdown(context.Background()))
+
+	exporter, err = New(next, Config{Dir: dir, MaxAge: time.Millisecond})
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("1")))
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans("2")))
+	assert.Equal(t, 1, exporter.Pending())
+	require.NoError(t, exporter.Shutdown(context.Background()))
+}
+
+func TestNew_EmptyDir(t *testing.T) {
+	_, err := New(&downstream{}, Config{})
+	assert.ErrorIs(t, err, ErrEmptyDir)
+}
@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/loghole/tracing/internal/forcekeep"
+	"github.com/loghole/tracing/internal/jaegerpropagator"
+)
+
+// Propagator names supported by NewPropagator, they match the OTEL_PROPAGATORS values.
+const (
+	PropagatorTraceContext = "tracecontext"
+	PropagatorBaggage      = "baggage"
+	PropagatorB3           = "b3"
+	PropagatorB3Multi      = "b3multi"
+	PropagatorJaeger       = "jaeger"
+	PropagatorNone         = "none"
+)
+
+//nolint:gochecknoglobals // propagator is shared by the middlewares like the otel global one.
+var _propagator atomic.Value
+
+type propagatorHolder struct {
+	propagation.TextMapPropagator
+}
+
+// NewPropagator returns the composite propagator of the named ones. Each propagator injects
+// own headers, on extract they are called in order and the context found by the later one wins.
+//
+// The jaeger propagator reads and writes uber-trace-id and uberctx-* headers of jaeger clients
+// including 64-bit trace ids, its debug flag forces tail samplers to keep the trace.
+func NewPropagator(names ...string) (propagation.TextMapPropagator, error) {
+	var propagators []propagation.TextMapPropagator
+
+	for _, name := range names {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case PropagatorTraceContext:
+			propagators = append(propagators, propagation.TraceContext{})
+		case PropagatorBaggage:
+			propagators = append(propagators, propagation.Baggage{})
+		case PropagatorB3:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case PropagatorB3Multi:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case PropagatorJaeger:
+			propagators = append(propagators, jaegerpropagator.Propagator{})
+		case PropagatorNone, "":
+		default:
+			return nil, fmt.Errorf("%w: unsupported propagator '%s', supported [%s]",
+				ErrInvalidConfiguration, name, "tracecontext, baggage, b3, b3multi, jaeger, none")
+		}
+	}
+
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
+
+func parsePropagators(value string) (propagation.TextMapPropagator, error) {
+	return NewPropagator(strings.Split(value, ",")...)
+}
+
+// Propagator returns the propagator used by Inject, Extract and the tracehttp and tracegrpc middlewares.
+// It is set by NewTracer from the Configuration.Propagator, tracecontext by default.
+func Propagator() propagation.TextMapPropagator {
+	if holder, ok := _propagator.Load().(propagatorHolder); ok {
+		return holder.TextMapPropagator
+	}
+
+	return propagation.TraceContext{}
+}
+
+func setPropagator(propagator propagation.TextMapPropagator) {
+	_propagator.Store(propagatorHolder{TextMapPropagator: propagator})
+}
+
+// Inject sets the trace context from the Context into the carrier with the Propagator.
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	Propagator().Inject(ctx, carrier)
+	forcekeep.Inject(ctx, carrier)
+}
+
+// Extract reads the trace context from the carrier with the Propagator and returns the Context
+// with the remote SpanContext.
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return Propagator().Extract(ctx, carrier)
+}
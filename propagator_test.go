@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/loghole/tracing/mocks"
+)
+
+func TestNewPropagator(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "tracecontext and baggage",
+			names: []string{"tracecontext", "baggage"},
+			want:  []string{"traceparent", "tracestate", "baggage"},
+		},
+		{
+			name:  "b3 single",
+			names: []string{"B3"},
+			want:  []string{"b3"},
+		},
+		{
+			name:  "b3 multi",
+			names: []string{"b3multi"},
+			want:  []string{"x-b3-traceid", "x-b3-spanid", "x-b3-sampled", "x-b3-flags"},
+		},
+		{
+			name:  "jaeger",
+			names: []string{" jaeger "},
+			want:  []string{"uber-trace-id"},
+		},
+		{
+			name:  "none",
+			names: []string{"none"},
+			want:  nil,
+		},
+		{
+			name:    "unknown",
+			names:   []string{"tracecontext", "xray"},
+			wantErr: "invalid configuration: unsupported propagator 'xray', supported [tracecontext, baggage, b3, b3multi, jaeger, none]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			propagator, err := NewPropagator(tt.names...)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, propagator.Fields())
+		})
+	}
+}
+
+func TestInject_Propagator(t *testing.T) {
+	propagator, err := NewPropagator("b3multi", "tracecontext")
+	require.NoError(t, err)
+
+	defer setPropagator(Propagator())
+
+	setPropagator(propagator)
+
+	carrier := http.Header{}
+
+	InjectHeaders(mocks.NewContextWithMockSpan(context.Background(), 1, 2), carrier)
+
+	assert.Equal(t, "01000000000000000000000000000000", carrier.Get("X-B3-Traceid"))
+	assert.Equal(t, "00-01000000000000000000000000000000-0200000000000000-00", carrier.Get("Traceparent"))
+
+	spanCtx := trace.SpanContextFromContext(Extract(context.Background(), propagation.HeaderCarrier(http.Header{
+		"X-B3-Traceid": []string{"03000000000000000000000000000000"},
+		"X-B3-Spanid":  []string{"0400000000000000"},
+	})))
+
+	assert.True(t, spanCtx.IsRemote())
+	assert.Equal(t, trace.TraceID{3}, spanCtx.TraceID())
+	assert.Equal(t, trace.SpanID{4}, spanCtx.SpanID())
+}
+
+func TestSpanBuilder_ExtractHeaders_Jaeger(t *testing.T) {
+	propagator, err := NewPropagator("tracecontext", "jaeger")
+	require.NoError(t, err)
+
+	defer setPropagator(Propagator())
+
+	setPropagator(propagator)
+
+	tracer, recorder := mocks.NewTracerWithRecorder()
+
+	span := SpanBuilder{tracer: tracer}.
+		WithName("handler").
+		ExtractHeaders(http.Header{"Uber-Trace-Id": []string{"a1b2:c3:0:1"}}).
+		Start(context.Background())
+	span.End()
+
+	require.Len(t, recorder.Ended(), 1)
+	assert.Equal(t, trace.TraceID{14: 0xa1, 15: 0xb2}, recorder.Ended()[0].SpanContext().TraceID())
+	assert.Equal(t, trace.SpanID{7: 0xc3}, recorder.Ended()[0].Parent().SpanID())
+}
+
+func TestNewPropagator_ExtractOrder(t *testing.T) {
+	propagator, err := NewPropagator("tracecontext", "b3multi")
+	require.NoError(t, err)
+
+	spanCtx := trace.SpanContextFromContext(propagator.Extract(context.Background(), propagation.HeaderCarrier(http.Header{
+		"Traceparent":  []string{"00-01000000000000000000000000000000-0200000000000000-01"},
+		"X-B3-Traceid": []string{"03000000000000000000000000000000"},
+		"X-B3-Spanid":  []string{"0400000000000000"},
+	})))
+
+	assert.Equal(t, trace.TraceID{3}, spanCtx.TraceID())
+	assert.Equal(t, trace.SpanID{4}, spanCtx.SpanID())
+}
@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ExporterFactory creates span exporter for the parsed Addr. Configuration contains
+// transport settings of the exporter: TLSConfig, Headers, Compression, Timeout and Encoding.
+// The ctx is used while the exporter is created, it is not kept by the exporter.
+type ExporterFactory func(ctx context.Context, u *url.URL, configuration *Configuration) (tracesdk.SpanExporter, error)
+
+//nolint:gochecknoglobals // exporters registry.
+var (
+	_exportersMu sync.RWMutex
+	_exporters   = make(map[string]ExporterFactory)
+)
+
+//nolint:gochecknoinits // register built-in exporters.
+func init() {
+	for scheme, factory := range map[string]ExporterFactory{
+		ExporterJaeger:   jaegerExporter,
+		"http":           jaegerExporter,
+		"https":          jaegerExporter,
+		"udp":            jaegerExporter,
+		ExporterOTLPGRPC: otlpGRPCExporter,
+		ExporterOTLPHTTP: otlpHTTPExporter,
+		ExporterZipkin:   zipkinExporter,
+		"zipkin+http":    zipkinExporter,
+		"zipkin+https":   zipkinExporter,
+		ExporterFile:     fileExporter,
+		ExporterStdout:   stdoutExporter,
+	} {
+		RegisterExporter(scheme, factory)
+	}
+}
+
+// RegisterExporter makes the exporter available by the Addr scheme or by the Configuration.Exporter name.
+// Scheme is case-insensitive. It panics if the factory is nil or the scheme is already registered.
+//
+// Example:
+//
+//	func init() {
+//	    tracing.RegisterExporter("kafka", func(
+//	        ctx context.Context, u *url.URL, c *tracing.Configuration,
+//	    ) (tracesdk.SpanExporter, error) {
+//	        return kafkaexporter.New(u.Host, u.Query().Get("topic"))
+//	    })
+//	}
+//
+// After that the exporter is enabled with Addr "kafka://broker:9092?topic=spans".
+func RegisterExporter(scheme string, factory ExporterFactory) {
+	if factory == nil {
+		panic("tracing: register nil exporter factory")
+	}
+
+	scheme = strings.ToLower(scheme)
+
+	if scheme == "" {
+		panic("tracing: register exporter with empty scheme")
+	}
+
+	_exportersMu.Lock()
+	defer _exportersMu.Unlock()
+
+	if _, ok := _exporters[scheme]; ok {
+		panic("tracing: register exporter twice for scheme " + scheme)
+	}
+
+	_exporters[scheme] = factory
+}
+
+func lookupExporter(scheme string) (ExporterFactory, bool) {
+	_exportersMu.RLock()
+	defer _exportersMu.RUnlock()
+
+	factory, ok := _exporters[strings.ToLower(scheme)]
+
+	return factory, ok
+}
+
+func registeredSchemes() []string {
+	_exportersMu.RLock()
+	defer _exportersMu.RUnlock()
+
+	schemes := make([]string, 0, len(_exporters))
+
+	for scheme := range _exporters {
+		schemes = append(schemes, scheme)
+	}
+
+	sort.Strings(schemes)
+
+	return schemes
+}
@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// memoryExporter keeps spans after shutdown.
+type memoryExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (e memoryExporter) Shutdown(context.Context) error { return nil }
+
+func TestRegisterExporter(t *testing.T) {
+	var (
+		recorder = memoryExporter{tracetest.NewInMemoryExporter()}
+		gotURL   *url.URL
+	)
+
+	RegisterExporter("Test+Memory", func(_ context.Context, u *url.URL, c *Configuration) (tracesdk.SpanExporter, error) {
+		gotURL = u
+
+		return recorder, nil
+	})
+
+	t.Cleanup(func() {
+		_exportersMu.Lock()
+		delete(_exporters, "test+memory")
+		_exportersMu.Unlock()
+	})
+
+	tracer, err := NewTracer(DefaultConfiguration("test", "test+memory://broker:9092?topic=spans"))
+	require.NoError(t, err)
+
+	_, span := tracer.Start(context.Background(), "root")
+	span.End()
+
+	require.NoError(t, tracer.Close())
+
+	assert.Equal(t, "broker:9092", gotURL.Host)
+	assert.Equal(t, "spans", gotURL.Query().Get("topic"))
+	require.Len(t, recorder.GetSpans(), 1)
+	assert.Equal(t, "root", recorder.GetSpans()[0].Name)
+
+	assert.Panics(t, func() {
+		RegisterExporter("TEST+MEMORY", func(context.Context, *url.URL, *Configuration) (tracesdk.SpanExporter, error) {
+			return recorder, nil
+		})
+	})
+	assert.Panics(t, func() { RegisterExporter("", stdoutExporter) })
+	assert.Panics(t, func() { RegisterExporter("nil", nil) })
+}
+
+func TestConfiguration_exporterUnknown(t *testing.T) {
+	_, err := (&Configuration{Addr: "kafka://127.0.0.1:9092"}).exporter(context.Background())
+	assert.ErrorIs(t, err, ErrInvalidConfiguration)
+	assert.EqualError(t, err, "invalid configuration: unknown exporter 'kafka', supported "+
+		"[file, http, https, jaeger, otlp+grpc, otlp+http, stdout, udp, zipkin, zipkin+http, zipkin+https]")
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Sampler names, they match OTEL_TRACES_SAMPLER values.
+const (
+	SamplerAlwaysOn                = "always_on"
+	SamplerAlwaysOff               = "always_off"
+	SamplerTraceIDRatio            = "traceidratio"
+	SamplerParentBasedAlwaysOn     = "parentbased_always_on"
+	SamplerParentBasedAlwaysOff    = "parentbased_always_off"
+	SamplerParentBasedTraceIDRatio = "parentbased_traceidratio"
+)
+
+// errInvalidSamplerArg marks errors of the sampler argument.
+var errInvalidSamplerArg = errors.New("sampler arg")
+
+// ParseSampler returns sampler by the name and the argument, argument is used
+// only by ratio based samplers and is 1.0 by default.
+func ParseSampler(name, arg string) (tracesdk.Sampler, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case SamplerAlwaysOn:
+		return tracesdk.AlwaysSample(), nil
+	case SamplerAlwaysOff:
+		return tracesdk.NeverSample(), nil
+	case SamplerTraceIDRatio:
+		ratio, err := parseSamplerRatio(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return tracesdk.TraceIDRatioBased(ratio), nil
+	case SamplerParentBasedAlwaysOn:
+		return tracesdk.ParentBased(tracesdk.AlwaysSample()), nil
+	case SamplerParentBasedAlwaysOff:
+		return tracesdk.ParentBased(tracesdk.NeverSample()), nil
+	case SamplerParentBasedTraceIDRatio:
+		ratio, err := parseSamplerRatio(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return tracesdk.ParentBased(tracesdk.TraceIDRatioBased(ratio)), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown sampler '%s'", ErrInvalidConfiguration, name)
+	}
+}
+
+// parseSamplerRatio parses the ratio of ratio based samplers, it is 1.0 by default.
+func parseSamplerRatio(arg string) (float64, error) {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return 1, nil
+	}
+
+	value, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: parse %w '%s': %w", ErrInvalidConfiguration, errInvalidSamplerArg, arg, err)
+	}
+
+	if value < 0 || value > 1 {
+		return 0, fmt.Errorf("%w: %w must be in range [0, 1], got %v", ErrInvalidConfiguration, errInvalidSamplerArg, value)
+	}
+
+	return value, nil
+}
@@ -19,12 +19,12 @@ func SpanContextFromContext(ctx context.Context) trace.SpanContext {
 	return SpanFromContext(ctx).SpanContext()
 }
 
-// InjectMap set tracecontext from the Context into the map[string]string carrier.
+// InjectMap set the trace context from the Context into the map[string]string carrier.
 func InjectMap(ctx context.Context, carrier map[string]string) {
-	new(propagation.TraceContext).Inject(ctx, propagation.MapCarrier(carrier))
+	Inject(ctx, propagation.MapCarrier(carrier))
 }
 
-// InjectHeaders set tracecontext from the Context into the http.Header carrier.
+// InjectHeaders set the trace context from the Context into the http.Header carrier.
 func InjectHeaders(ctx context.Context, carrier http.Header) {
-	new(propagation.TraceContext).Inject(ctx, propagation.HeaderCarrier(carrier))
+	Inject(ctx, propagation.HeaderCarrier(carrier))
 }
@@ -0,0 +1,41 @@
+package spanprocessor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var _ tracesdk.SpanProcessor = new(Baggage)
+
+// Baggage copies baggage members of the parent context to attributes of started spans,
+// it must be registered before processors which read attributes.
+type Baggage struct {
+	keys []string
+}
+
+// NewBaggage returns the processor which copies the baggage members with the keys.
+func NewBaggage(keys ...string) *Baggage {
+	return &Baggage{keys: keys}
+}
+
+func (p *Baggage) OnStart(parent context.Context, span tracesdk.ReadWriteSpan) {
+	bag := baggage.FromContext(parent)
+	if bag.Len() == 0 {
+		return
+	}
+
+	for _, key := range p.keys {
+		if member := bag.Member(key); member.Key() != "" {
+			span.SetAttributes(attribute.String(key, member.Value()))
+		}
+	}
+}
+
+func (p *Baggage) OnEnd(tracesdk.ReadOnlySpan) {}
+
+func (p *Baggage) Shutdown(context.Context) error { return nil }
+
+func (p *Baggage) ForceFlush(context.Context) error { return nil }
@@ -0,0 +1,38 @@
+package spanprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestBaggage(t *testing.T) {
+	var (
+		recorder = tracetest.NewSpanRecorder()
+		tracer   = tracesdk.NewTracerProvider(
+			tracesdk.WithSpanProcessor(NewBaggage("tenant.id", "missing")),
+			tracesdk.WithSpanProcessor(recorder),
+		).Tracer("")
+	)
+
+	tenant, err := baggage.NewMember("tenant.id", "42")
+	require.NoError(t, err)
+
+	other, err := baggage.NewMember("other", "value")
+	require.NoError(t, err)
+
+	bag, err := baggage.New(tenant, other)
+	require.NoError(t, err)
+
+	_, span := tracer.Start(baggage.ContextWithBaggage(context.Background(), bag), "span")
+	span.End()
+
+	require.Len(t, recorder.Ended(), 1)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("tenant.id", "42")}, recorder.Ended()[0].Attributes())
+}
@@ -0,0 +1,113 @@
+package spanprocessor
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+const _exceptionEventName = "exception"
+
+// ErrorPredicate reports whether the span has error, traces with errors are always sampled.
+type ErrorPredicate func(span tracesdk.ReadOnlySpan) bool
+
+// WithErrorPredicate replaces the DefaultErrorPredicate.
+func WithErrorPredicate(predicate ErrorPredicate) Option {
+	return func(p *Sampled) {
+		if predicate != nil {
+			p.isError = predicate
+		}
+	}
+}
+
+// DefaultErrorPredicate detects errors by the span status and the error attribute.
+func DefaultErrorPredicate(span tracesdk.ReadOnlySpan) bool {
+	return StatusError(span) || ErrorAttribute(span)
+}
+
+// AnyError matches if any predicate matches.
+func AnyError(predicates ...ErrorPredicate) ErrorPredicate {
+	return func(span tracesdk.ReadOnlySpan) bool {
+		for _, predicate := range predicates {
+			if predicate(span) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// StatusError matches spans with the Error status.
+func StatusError(span tracesdk.ReadOnlySpan) bool {
+	return span.Status().Code == codes.Error
+}
+
+// ErrorAttribute matches spans with the attribute which key case-insensitively equals "error",
+// false, empty and "false" values are not errors.
+func ErrorAttribute(span tracesdk.ReadOnlySpan) bool {
+	for _, attr := range span.Attributes() {
+		if !strings.EqualFold(string(attr.Key), "error") {
+			continue
+		}
+
+		switch attr.Value.Type() { //nolint:exhaustive // other types are errors.
+		case attribute.BOOL:
+			return attr.Value.AsBool()
+		case attribute.STRING:
+			value := attr.Value.AsString()
+
+			return value != "" && !strings.EqualFold(value, "false")
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExceptionEvent matches spans with the exception event, e.g. recorded by span.RecordError.
+func ExceptionEvent(span tracesdk.ReadOnlySpan) bool {
+	for _, event := range span.Events() {
+		if event.Name == _exceptionEventName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HTTPServerError matches spans with the http.status_code attribute 500 or greater.
+func HTTPServerError(span tracesdk.ReadOnlySpan) bool {
+	for _, attr := range span.Attributes() {
+		if attr.Key == semconv.HTTPStatusCodeKey && attr.Value.Type() == attribute.INT64 {
+			return attr.Value.AsInt64() >= 500 //nolint:gomnd // server errors.
+		}
+	}
+
+	return false
+}
+
+// GRPCStatusCodes matches spans with the rpc.grpc.status_code attribute in the codes.
+func GRPCStatusCodes(codes ...int64) ErrorPredicate {
+	set := make(map[int64]struct{}, len(codes))
+
+	for _, code := range codes {
+		set[code] = struct{}{}
+	}
+
+	return func(span tracesdk.ReadOnlySpan) bool {
+		for _, attr := range span.Attributes() {
+			if attr.Key == semconv.RPCGRPCStatusCodeKey && attr.Value.Type() == attribute.INT64 {
+				_, ok := set[attr.Value.AsInt64()]
+
+				return ok
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,127 @@
+package spanprocessor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestErrorPredicates(t *testing.T) {
+	tests := []struct {
+		name      string
+		predicate ErrorPredicate
+		span      tracetest.SpanStub
+		want      bool
+	}{
+		{
+			name:      "status error",
+			predicate: StatusError,
+			span:      tracetest.SpanStub{Status: tracesdk.Status{Code: codes.Error}},
+			want:      true,
+		},
+		{
+			name:      "status ok",
+			predicate: StatusError,
+			span:      tracetest.SpanStub{Status: tracesdk.Status{Code: codes.Ok}},
+			want:      false,
+		},
+		{
+			name:      "error attribute true",
+			predicate: ErrorAttribute,
+			span:      tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Bool("Error", true)}},
+			want:      true,
+		},
+		{
+			name:      "error attribute false",
+			predicate: ErrorAttribute,
+			span:      tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Bool("error", false)}},
+			want:      false,
+		},
+		{
+			name:      "error attribute string false",
+			predicate: ErrorAttribute,
+			span:      tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.String("error", "false")}},
+			want:      false,
+		},
+		{
+			name:      "error attribute message",
+			predicate: ErrorAttribute,
+			span:      tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.String("error", "timeout")}},
+			want:      true,
+		},
+		{
+			name:      "exception event",
+			predicate: ExceptionEvent,
+			span:      tracetest.SpanStub{Events: []tracesdk.Event{{Name: "exception"}}},
+			want:      true,
+		},
+		{
+			name:      "other event",
+			predicate: ExceptionEvent,
+			span:      tracetest.SpanStub{Events: []tracesdk.Event{{Name: "retry"}}},
+			want:      false,
+		},
+		{
+			name:      "http 503",
+			predicate: HTTPServerError,
+			span:      tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Int("http.status_code", 503)}},
+			want:      true,
+		},
+		{
+			name:      "http 404",
+			predicate: HTTPServerError,
+			span:      tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Int("http.status_code", 404)}},
+			want:      false,
+		},
+		{
+			name:      "grpc code in set",
+			predicate: GRPCStatusCodes(13, 14),
+			span:      tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Int("rpc.grpc.status_code", 14)}},
+			want:      true,
+		},
+		{
+			name:      "grpc code not in set",
+			predicate: GRPCStatusCodes(13, 14),
+			span:      tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Int("rpc.grpc.status_code", 5)}},
+			want:      false,
+		},
+		{
+			name:      "any",
+			predicate: AnyError(StatusError, HTTPServerError),
+			span:      tracetest.SpanStub{Attributes: []attribute.KeyValue{attribute.Int("http.status_code", 500)}},
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.predicate(tt.span.Snapshot()))
+		})
+	}
+}
+
+func TestSampled_ErrorPredicate(t *testing.T) {
+	var (
+		recorder  = tracetest.NewSpanRecorder()
+		processor = NewSampled(recorder, tracesdk.NeverSample(), WithErrorPredicate(ExceptionEvent))
+		tracer    = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor)).Tracer("")
+	)
+
+	_, span := tracer.Start(context.Background(), "status")
+	span.SetStatus(codes.Error, "")
+	span.End()
+
+	assert.Empty(t, recorder.Ended())
+
+	_, span = tracer.Start(context.Background(), "exception")
+	span.RecordError(errors.New("failed"))
+	span.End()
+
+	assert.Len(t, recorder.Ended(), 1)
+}
@@ -0,0 +1,230 @@
+package spanprocessor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Branch is a downstream processor of Fanout with optional sampler and filter.
+type Branch struct {
+	Processor tracesdk.SpanProcessor
+	// Sampler samples traces for the branch only, nil means all spans are passed.
+	// It decides once per trace by the local root span.
+	Sampler tracesdk.Sampler
+	// Filter drops spans for which returns false, nil means all spans are passed.
+	Filter func(span tracesdk.ReadOnlySpan) bool
+}
+
+func (b *Branch) shouldSample(root tracesdk.ReadOnlySpan) bool {
+	parent := context.Background()
+	if root.Parent().IsValid() {
+		parent = trace.ContextWithRemoteSpanContext(parent, root.Parent())
+	}
+
+	result := b.Sampler.ShouldSample(tracesdk.SamplingParameters{
+		ParentContext: parent,
+		TraceID:       root.SpanContext().TraceID(),
+		Name:          root.Name(),
+		Kind:          root.SpanKind(),
+		Attributes:    root.Attributes(),
+	})
+
+	return result.Decision == tracesdk.RecordAndSample
+}
+
+// _decisionTTL limits how long decisions of traces with never ended local roots are kept.
+const _decisionTTL = 10 * time.Minute
+
+// Fanout sends spans to the all branches. Branches are independent:
+// shutdown and flush are called concurrently and errors are joined.
+//
+// Samplers of branches decide when the local root of the trace is started, decisions are kept
+// until the local root is ended, but not longer than 10 minutes. The local root is taken from
+// the parent context of OnStart if it is not started through the Fanout, e.g. the Sampled passes
+// it with each span.
+type Fanout struct {
+	branches []Branch
+	sampled  bool
+
+	decisions map[trace.TraceID]decision
+	expireAt  time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+type decision struct {
+	branches []bool
+	expires  time.Time
+}
+
+func NewFanout(branches ...Branch) *Fanout {
+	p := &Fanout{
+		branches:  branches,
+		decisions: make(map[trace.TraceID]decision),
+		now:       time.Now,
+	}
+
+	for idx := range branches {
+		if branches[idx].Sampler != nil {
+			p.sampled = true
+		}
+	}
+
+	return p
+}
+
+func (p *Fanout) OnStart(parent context.Context, span tracesdk.ReadWriteSpan) {
+	if p.sampled {
+		if root := localRoot(parent, span); root != nil {
+			p.decide(root)
+		}
+	}
+
+	for _, branch := range p.branches {
+		branch.Processor.OnStart(parent, span)
+	}
+}
+
+func (p *Fanout) OnEnd(span tracesdk.ReadOnlySpan) {
+	var decisions []bool
+
+	if p.sampled {
+		decisions = p.decisionsOf(span)
+	}
+
+	for idx := range p.branches {
+		branch := &p.branches[idx]
+
+		if branch.Filter != nil && !branch.Filter(span) {
+			continue
+		}
+
+		if branch.Sampler != nil && !decisions[idx] {
+			continue
+		}
+
+		branch.Processor.OnEnd(span)
+	}
+}
+
+func (p *Fanout) Shutdown(ctx context.Context) error {
+	return p.each(func(processor tracesdk.SpanProcessor) error {
+		return processor.Shutdown(ctx)
+	})
+}
+
+func (p *Fanout) ForceFlush(ctx context.Context) error {
+	return p.each(func(processor tracesdk.SpanProcessor) error {
+		return processor.ForceFlush(ctx)
+	})
+}
+
+// decide makes decisions of branches for the trace of the local root if they are not made yet.
+func (p *Fanout) decide(root tracesdk.ReadOnlySpan) {
+	traceID := root.SpanContext().TraceID()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+
+	p.expire(now)
+
+	if _, ok := p.decisions[traceID]; ok {
+		return
+	}
+
+	p.decisions[traceID] = decision{branches: p.sample(root), expires: now.Add(_decisionTTL)}
+}
+
+// expire forgets expired decisions once per the TTL, must be called under the lock.
+func (p *Fanout) expire(now time.Time) {
+	if now.Before(p.expireAt) {
+		return
+	}
+
+	for traceID, decision := range p.decisions {
+		if !now.Before(decision.expires) {
+			delete(p.decisions, traceID)
+		}
+	}
+
+	p.expireAt = now.Add(_decisionTTL)
+}
+
+// decisionsOf returns decisions of branches for the trace of the span, they are forgotten
+// when the local root ends. Spans without the decision are sampled by themselves.
+func (p *Fanout) decisionsOf(span tracesdk.ReadOnlySpan) []bool {
+	traceID := span.SpanContext().TraceID()
+
+	p.mu.Lock()
+
+	decision, ok := p.decisions[traceID]
+	if ok && isLocalRoot(span) {
+		delete(p.decisions, traceID)
+	}
+
+	p.mu.Unlock()
+
+	if !ok {
+		return p.sample(span)
+	}
+
+	return decision.branches
+}
+
+func (p *Fanout) sample(root tracesdk.ReadOnlySpan) []bool {
+	decisions := make([]bool, len(p.branches))
+
+	for idx := range p.branches {
+		if p.branches[idx].Sampler != nil {
+			decisions[idx] = p.branches[idx].shouldSample(root)
+		}
+	}
+
+	return decisions
+}
+
+func (p *Fanout) each(fn func(processor tracesdk.SpanProcessor) error) error {
+	var (
+		errs = make([]error, len(p.branches))
+		wg   sync.WaitGroup
+	)
+
+	for idx := range p.branches {
+		wg.Add(1)
+
+		go func(idx int) {
+			defer wg.Done()
+
+			errs[idx] = fn(p.branches[idx].Processor)
+		}(idx)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// localRoot returns the local root of the span trace: the span itself or the span of the parent context.
+func localRoot(parent context.Context, span tracesdk.ReadOnlySpan) tracesdk.ReadOnlySpan {
+	if isLocalRoot(span) {
+		return span
+	}
+
+	root, ok := trace.SpanFromContext(parent).(tracesdk.ReadOnlySpan)
+	if ok && root.SpanContext().TraceID() == span.SpanContext().TraceID() && isLocalRoot(root) {
+		return root
+	}
+
+	return nil
+}
+
+func isLocalRoot(span tracesdk.ReadOnlySpan) bool {
+	return !span.Parent().IsValid() || span.Parent().IsRemote()
+}
@@ -0,0 +1,150 @@
+package spanprocessor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestFanout(t *testing.T) {
+	var (
+		all      = tracetest.NewSpanRecorder()
+		filtered = tracetest.NewSpanRecorder()
+		never    = tracetest.NewSpanRecorder()
+		fanout   = NewFanout(
+			Branch{Processor: all},
+			Branch{Processor: filtered, Filter: func(span tracesdk.ReadOnlySpan) bool { return span.Name() == "keep" }},
+			Branch{Processor: never, Sampler: tracesdk.NeverSample()},
+		)
+		tracer = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(NewSampled(fanout, tracesdk.AlwaysSample()))).Tracer("")
+	)
+
+	ctx, span := tracer.Start(context.Background(), "keep")
+	_, child := tracer.Start(ctx, "drop")
+	child.End()
+	span.End()
+
+	assert.Len(t, all.Ended(), 2)
+	assert.Len(t, filtered.Ended(), 1)
+	assert.Len(t, never.Ended(), 0)
+}
+
+// nameSampler samples traces with the root span name.
+type nameSampler string
+
+func (s nameSampler) ShouldSample(parameters tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	if parameters.Name == string(s) {
+		return tracesdk.SamplingResult{Decision: tracesdk.RecordAndSample}
+	}
+
+	return tracesdk.SamplingResult{Decision: tracesdk.Drop}
+}
+
+func (s nameSampler) Description() string {
+	return "name"
+}
+
+func TestFanout_SamplerPerTrace(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor func(fanout *Fanout) tracesdk.SpanProcessor
+	}{
+		{
+			name:      "direct",
+			processor: func(fanout *Fanout) tracesdk.SpanProcessor { return fanout },
+		},
+		{
+			name: "sampled",
+			processor: func(fanout *Fanout) tracesdk.SpanProcessor {
+				return NewSampled(fanout, tracesdk.AlwaysSample())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				recorder = tracetest.NewSpanRecorder()
+				fanout   = NewFanout(Branch{Processor: recorder, Sampler: nameSampler("keep")})
+				tracer   = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(tt.processor(fanout))).Tracer("")
+			)
+
+			ctx, root := tracer.Start(context.Background(), "keep")
+			_, child := tracer.Start(ctx, "child")
+			child.End()
+			root.End()
+
+			ctx, root = tracer.Start(context.Background(), "drop")
+			_, child = tracer.Start(ctx, "keep")
+			child.End()
+			root.End()
+
+			names := make([]string, 0, len(recorder.Ended()))
+
+			for _, span := range recorder.Ended() {
+				names = append(names, span.Name())
+			}
+
+			assert.ElementsMatch(t, []string{"keep", "child"}, names)
+			assert.Empty(t, fanout.decisions, "decisions are forgotten after the root ends")
+		})
+	}
+}
+
+func TestFanout_DecisionTTL(t *testing.T) {
+	var (
+		now    = time.Now()
+		fanout = NewFanout(Branch{Processor: tracetest.NewSpanRecorder(), Sampler: nameSampler("keep")})
+		tracer = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(fanout)).Tracer("")
+	)
+
+	fanout.now = func() time.Time { return now }
+
+	// Roots are never ended, e.g. evicted by the Sampled.
+	for idx := 0; idx < 100; idx++ {
+		tracer.Start(context.Background(), "keep")
+	}
+
+	assert.Len(t, fanout.decisions, 100)
+
+	now = now.Add(_decisionTTL)
+
+	tracer.Start(context.Background(), "keep")
+
+	assert.Len(t, fanout.decisions, 1)
+}
+
+func TestFanout_Shutdown(t *testing.T) {
+	var (
+		errFailed = errors.New("failed")
+		slow      = &funcProcessor{shutdown: func(ctx context.Context) error {
+			<-ctx.Done()
+
+			return ctx.Err()
+		}}
+		failed = &funcProcessor{shutdown: func(ctx context.Context) error { return errFailed }}
+		ok     = tracetest.NewSpanRecorder()
+		fanout = NewFanout(Branch{Processor: slow}, Branch{Processor: failed}, Branch{Processor: ok})
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := fanout.Shutdown(ctx)
+	assert.ErrorIs(t, err, errFailed)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NoError(t, ok.Shutdown(context.Background()))
+}
+
+type funcProcessor struct {
+	NoopSpanProcessor
+
+	shutdown func(ctx context.Context) error
+}
+
+func (p *funcProcessor) Shutdown(ctx context.Context) error { return p.shutdown(ctx) }
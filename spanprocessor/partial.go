@@ -0,0 +1,45 @@
+package spanprocessor
+
+import (
+	"time"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// PartialFlush makes sampling decision before the root span is ended. After the decision
+// ended spans of the sampled trace are sent and the next spans are sent as soon as they end,
+// spans of the not sampled trace are dropped. Thresholds are checked when a span of the trace ends.
+type PartialFlush struct {
+	// OnError decides when a span with error ends.
+	OnError bool
+	// MaxSpans decides when the trace has at least MaxSpans buffered spans, zero means no limit.
+	MaxSpans int
+	// MaxAge decides when the trace was started at least MaxAge ago, zero means no limit.
+	MaxAge time.Duration
+}
+
+// WithPartialFlush enables flushing of long-lived traces before the root span is ended.
+func WithPartialFlush(flush PartialFlush) Option {
+	return func(p *Sampled) {
+		if flush.OnError || flush.MaxSpans > 0 || flush.MaxAge > 0 {
+			p.partial = &flush
+		}
+	}
+}
+
+// shouldFlush reports whether the trace should be decided on the end of the child span,
+// must be called under the shard lock.
+func (p *Sampled) shouldFlush(wr *wrapper, span tracesdk.ReadOnlySpan) bool {
+	switch {
+	case wr.decided:
+		return true
+	case p.partial.OnError && p.isError(span):
+		return true
+	case p.partial.MaxSpans > 0 && len(wr.spans) >= p.partial.MaxSpans:
+		return true
+	case p.partial.MaxAge > 0 && p.now().Sub(wr.started) >= p.partial.MaxAge:
+		return true
+	default:
+		return false
+	}
+}
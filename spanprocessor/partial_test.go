@@ -0,0 +1,114 @@
+package spanprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSampled_PartialFlush(t *testing.T) {
+	tests := []struct {
+		name    string
+		sampler tracesdk.Sampler
+		flush   PartialFlush
+		run     func(t *testing.T, p *Sampled, tracer trace.Tracer, recorder *tracetest.SpanRecorder)
+	}{
+		{
+			name:    "on error",
+			sampler: tracesdk.NeverSample(),
+			flush:   PartialFlush{OnError: true},
+			run: func(t *testing.T, p *Sampled, tracer trace.Tracer, recorder *tracetest.SpanRecorder) {
+				ctx, root := tracer.Start(context.Background(), "stream")
+
+				_, ok := tracer.Start(ctx, "ok")
+				ok.End()
+
+				assert.Empty(t, recorder.Ended())
+
+				_, failed := tracer.Start(ctx, "failed")
+				failed.SetStatus(codes.Error, "")
+				failed.End()
+
+				assert.Len(t, recorder.Ended(), 2, "buffered spans are flushed")
+
+				_, next := tracer.Start(ctx, "next")
+				next.End()
+
+				assert.Len(t, recorder.Ended(), 3, "next spans are sent directly")
+
+				root.End()
+
+				assert.Len(t, recorder.Ended(), 4)
+				assert.Zero(t, p.len())
+			},
+		},
+		{
+			name:    "max spans not sampled",
+			sampler: tracesdk.NeverSample(),
+			flush:   PartialFlush{MaxSpans: 3},
+			run: func(t *testing.T, p *Sampled, tracer trace.Tracer, recorder *tracetest.SpanRecorder) {
+				ctx, root := tracer.Start(context.Background(), "stream")
+
+				for i := 0; i < 5; i++ {
+					_, span := tracer.Start(ctx, "message")
+					span.End()
+				}
+
+				assert.Equal(t, 1, p.len())
+				assert.Len(t, p.shard(root.SpanContext().TraceID()).traces[root.SpanContext().TraceID()].spans, 1,
+					"only the root span is buffered")
+
+				_, failed := tracer.Start(ctx, "failed")
+				failed.SetStatus(codes.Error, "")
+				failed.End()
+
+				root.End()
+
+				assert.Empty(t, recorder.Ended())
+				assert.Zero(t, p.len())
+			},
+		},
+		{
+			name:    "max age",
+			sampler: tracesdk.AlwaysSample(),
+			flush:   PartialFlush{MaxAge: time.Minute},
+			run: func(t *testing.T, p *Sampled, tracer trace.Tracer, recorder *tracetest.SpanRecorder) {
+				ctx, root := tracer.Start(context.Background(), "job")
+
+				_, first := tracer.Start(ctx, "first")
+				first.End()
+
+				assert.Empty(t, recorder.Ended())
+
+				p.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+				_, second := tracer.Start(ctx, "second")
+				second.End()
+
+				assert.Len(t, recorder.Ended(), 2)
+
+				root.End()
+
+				assert.Len(t, recorder.Ended(), 3)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				recorder  = tracetest.NewSpanRecorder()
+				processor = NewSampled(recorder, tt.sampler, WithPartialFlush(tt.flush))
+				tracer    = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor)).Tracer("")
+			)
+
+			tt.run(t, processor, tracer, recorder)
+		})
+	}
+}
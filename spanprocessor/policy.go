@@ -0,0 +1,269 @@
+package spanprocessor
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PolicyAttributeKey is set on the root span to the name of the policy which made the decision.
+const PolicyAttributeKey = attribute.Key("sampling.policy")
+
+// Trace is the buffered trace passed to the policy conditions.
+type Trace struct {
+	// Root is the first span of the trace in this process.
+	Root tracesdk.ReadOnlySpan
+	// Spans are all buffered spans of the trace including the Root, some of them can be not ended yet.
+	Spans []tracesdk.ReadOnlySpan
+}
+
+// Condition matches the buffered trace.
+type Condition interface {
+	Match(t *Trace) bool
+}
+
+// ConditionFunc is an adapter to use ordinary function as the Condition.
+type ConditionFunc func(t *Trace) bool
+
+func (f ConditionFunc) Match(t *Trace) bool {
+	return f(t)
+}
+
+// Policy makes sampling decision for traces matched by the Condition.
+type Policy struct {
+	Name string
+	// Condition matches the trace, nil Condition matches all traces.
+	Condition Condition
+	// Drop drops matched traces, they are kept by default.
+	Drop bool
+}
+
+// WithPolicies sets policies which are evaluated in order before the default error, latency and sampler checks.
+// The first matched policy makes the decision and its name is recorded in the sampling.policy attribute
+// of the root span.
+func WithPolicies(policies ...Policy) Option {
+	return func(p *Sampled) {
+		for _, policy := range policies {
+			if policy.Condition == nil {
+				policy.Condition = And()
+			}
+
+			p.policies = append(p.policies, policy)
+		}
+	}
+}
+
+// And matches if all conditions match, conditions are evaluated in order until the first mismatch.
+func And(conditions ...Condition) Condition {
+	return ConditionFunc(func(t *Trace) bool {
+		for _, condition := range conditions {
+			if !condition.Match(t) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// Or matches if any condition matches, conditions are evaluated in order until the first match.
+func Or(conditions ...Condition) Condition {
+	return ConditionFunc(func(t *Trace) bool {
+		for _, condition := range conditions {
+			if condition.Match(t) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Not inverts the condition.
+func Not(condition Condition) Condition {
+	return ConditionFunc(func(t *Trace) bool {
+		return !condition.Match(t)
+	})
+}
+
+// AttributeEquals matches if any span has the attribute with the value, non-string values are compared
+// by the string form.
+func AttributeEquals(key, value string) Condition {
+	return anyAttribute(attribute.Key(key), func(v attribute.Value) bool {
+		return v.Emit() == value
+	})
+}
+
+// AttributeRegex matches if any span has the attribute which string form matches the regexp.
+func AttributeRegex(key string, re *regexp.Regexp) Condition {
+	return anyAttribute(attribute.Key(key), func(v attribute.Value) bool {
+		return re.MatchString(v.Emit())
+	})
+}
+
+// AttributeRange matches if any span has the numeric attribute in range [min, max].
+func AttributeRange(key string, min, max float64) Condition {
+	return anyAttribute(attribute.Key(key), func(v attribute.Value) bool {
+		var value float64
+
+		switch v.Type() { //nolint:exhaustive // only numbers.
+		case attribute.INT64:
+			value = float64(v.AsInt64())
+		case attribute.FLOAT64:
+			value = v.AsFloat64()
+		default:
+			return false
+		}
+
+		return value >= min && value <= max
+	})
+}
+
+// StatusCode matches if any span has the status code.
+func StatusCode(code codes.Code) Condition {
+	return anySpan(func(span tracesdk.ReadOnlySpan) bool {
+		return span.Status().Code == code
+	})
+}
+
+// Latency matches if any ended span lasts at least the threshold.
+func Latency(threshold time.Duration) Condition {
+	return anySpan(func(span tracesdk.ReadOnlySpan) bool {
+		return !span.EndTime().IsZero() && span.EndTime().Sub(span.StartTime()) >= threshold
+	})
+}
+
+// SpanCount matches if number of buffered spans is in range [min, max], zero max means no upper limit.
+func SpanCount(min, max int) Condition {
+	return ConditionFunc(func(t *Trace) bool {
+		return len(t.Spans) >= min && (max == 0 || len(t.Spans) <= max)
+	})
+}
+
+// SpanKind matches if any span has the kind.
+func SpanKind(kind trace.SpanKind) Condition {
+	return anySpan(func(span tracesdk.ReadOnlySpan) bool {
+		return span.SpanKind() == kind
+	})
+}
+
+// ServiceName matches if the service.name resource attribute of the root span equals the name.
+func ServiceName(name string) Condition {
+	return ConditionFunc(func(t *Trace) bool {
+		if t.Root.Resource() == nil {
+			return false
+		}
+
+		value, ok := t.Root.Resource().Set().Value(semconv.ServiceNameKey)
+
+		return ok && value.AsString() == name
+	})
+}
+
+// Probabilistic matches the fraction of traces, the decision depends only on the trace id.
+func Probabilistic(fraction float64) Condition {
+	sampler := tracesdk.TraceIDRatioBased(fraction)
+
+	return ConditionFunc(func(t *Trace) bool {
+		result := sampler.ShouldSample(tracesdk.SamplingParameters{TraceID: t.Root.SpanContext().TraceID()})
+
+		return result.Decision == tracesdk.RecordAndSample
+	})
+}
+
+// RateLimited matches at most perSecond traces per second.
+func RateLimited(perSecond float64) Condition {
+	limiter := newTokenBucket(perSecond, time.Now)
+
+	return ConditionFunc(func(*Trace) bool {
+		return limiter.allow()
+	})
+}
+
+func anySpan(match func(span tracesdk.ReadOnlySpan) bool) Condition {
+	return ConditionFunc(func(t *Trace) bool {
+		for _, span := range t.Spans {
+			if match(span) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+func anyAttribute(key attribute.Key, match func(v attribute.Value) bool) Condition {
+	return anySpan(func(span tracesdk.ReadOnlySpan) bool {
+		for _, attr := range span.Attributes() {
+			if attr.Key == key && match(attr.Value) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// tokenBucket allows rate events per second with burst of the same size, but at least one.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+
+	mu sync.Mutex
+}
+
+func newTokenBucket(rate float64, now func() time.Time) *tokenBucket {
+	burst := rate
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now(), now: now}
+}
+
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	b.last = now
+
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// policySpan adds the sampling.policy attribute to the root span.
+type policySpan struct {
+	tracesdk.ReadWriteSpan
+
+	policy string
+}
+
+func (s *policySpan) Attributes() []attribute.KeyValue {
+	attributes := s.ReadWriteSpan.Attributes()
+
+	result := make([]attribute.KeyValue, 0, len(attributes)+1)
+	result = append(result, attributes...)
+
+	return append(result, PolicyAttributeKey.String(s.policy))
+}
@@ -0,0 +1,202 @@
+package spanprocessor
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSampled_Policies(t *testing.T) {
+	tests := []struct {
+		name       string
+		sampler    tracesdk.Sampler
+		policies   []Policy
+		attributes []attribute.KeyValue
+		status     codes.Code
+		want       int
+		wantPolicy string
+	}{
+		{
+			name:       "keep",
+			sampler:    tracesdk.NeverSample(),
+			policies:   []Policy{{Name: "users", Condition: AttributeEquals("http.route", "/users")}},
+			attributes: []attribute.KeyValue{attribute.String("http.route", "/users")},
+			want:       2,
+			wantPolicy: "users",
+		},
+		{
+			name:       "drop error",
+			sampler:    tracesdk.AlwaysSample(),
+			policies:   []Policy{{Name: "health", Condition: AttributeRegex("http.route", regexp.MustCompile("^/health")), Drop: true}},
+			attributes: []attribute.KeyValue{attribute.String("http.route", "/health/live")},
+			status:     codes.Error,
+			want:       0,
+		},
+		{
+			name:    "first match wins",
+			sampler: tracesdk.AlwaysSample(),
+			policies: []Policy{
+				{Name: "drop", Condition: StatusCode(codes.Error), Drop: true},
+				{Name: "keep", Condition: StatusCode(codes.Error)},
+			},
+			status: codes.Error,
+			want:   0,
+		},
+		{
+			name:       "no match falls back to sampler",
+			sampler:    tracesdk.AlwaysSample(),
+			policies:   []Policy{{Name: "never", Condition: Not(SpanCount(0, 0)), Drop: true}},
+			want:       2,
+			wantPolicy: "",
+		},
+		{
+			name:    "no match falls back to error check",
+			sampler: tracesdk.NeverSample(),
+			policies: []Policy{{Name: "range", Condition: And(
+				AttributeRange("http.status_code", 500, 599),
+				SpanKind(trace.SpanKindServer),
+			)}},
+			attributes: []attribute.KeyValue{attribute.Int("http.status_code", 404)},
+			status:     codes.Error,
+			want:       2,
+		},
+		{
+			name:       "or",
+			sampler:    tracesdk.NeverSample(),
+			policies:   []Policy{{Name: "or", Condition: Or(StatusCode(codes.Ok), SpanCount(2, 2))}},
+			want:       2,
+			wantPolicy: "or",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				recorder  = tracetest.NewSpanRecorder()
+				processor = NewSampled(recorder, tt.sampler, WithPolicies(tt.policies...))
+				tracer    = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor)).Tracer("")
+			)
+
+			ctx, root := tracer.Start(context.Background(), "root", trace.WithSpanKind(trace.SpanKindClient))
+
+			_, child := tracer.Start(ctx, "child", trace.WithAttributes(tt.attributes...))
+			child.SetStatus(tt.status, "")
+			child.End()
+
+			root.End()
+
+			assert.Len(t, recorder.Ended(), tt.want)
+
+			for _, span := range recorder.Ended() {
+				value, ok := attributeValue(span.Attributes(), PolicyAttributeKey)
+
+				if span.Name() == "root" && tt.wantPolicy != "" {
+					assert.True(t, ok)
+					assert.Equal(t, tt.wantPolicy, value.AsString())
+				} else {
+					assert.False(t, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestConditions(t *testing.T) {
+	start := time.Now()
+
+	root := tracetest.SpanStub{
+		Name:        "root",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{0x01}, SpanID: trace.SpanID{0x01}}),
+		SpanKind:    trace.SpanKindServer,
+		StartTime:   start,
+		EndTime:     start.Add(time.Second),
+		Resource:    resource.NewSchemaless(semconv.ServiceNameKey.String("api")),
+	}.Snapshot()
+
+	child := tracetest.SpanStub{
+		Name:       "child",
+		SpanKind:   trace.SpanKindClient,
+		StartTime:  start,
+		Attributes: []attribute.KeyValue{attribute.Float64("ratio", 0.5), attribute.Bool("cache", true)},
+		Status:     tracesdk.Status{Code: codes.Error},
+	}.Snapshot()
+
+	tr := &Trace{Root: root, Spans: []tracesdk.ReadOnlySpan{root, child}}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		want      bool
+	}{
+		{name: "equals bool", condition: AttributeEquals("cache", "true"), want: true},
+		{name: "equals missing", condition: AttributeEquals("missing", ""), want: false},
+		{name: "regex", condition: AttributeRegex("cache", regexp.MustCompile("^t")), want: true},
+		{name: "range float", condition: AttributeRange("ratio", 0, 1), want: true},
+		{name: "range not number", condition: AttributeRange("cache", 0, 1), want: false},
+		{name: "status", condition: StatusCode(codes.Error), want: true},
+		{name: "status ok", condition: StatusCode(codes.Ok), want: false},
+		{name: "latency", condition: Latency(time.Second), want: true},
+		{name: "latency not ended", condition: Latency(time.Hour), want: false},
+		{name: "span count", condition: SpanCount(2, 0), want: true},
+		{name: "span count max", condition: SpanCount(0, 1), want: false},
+		{name: "kind", condition: SpanKind(trace.SpanKindClient), want: true},
+		{name: "kind producer", condition: SpanKind(trace.SpanKindProducer), want: false},
+		{name: "service", condition: ServiceName("api"), want: true},
+		{name: "other service", condition: ServiceName("web"), want: false},
+		{name: "probability all", condition: Probabilistic(1), want: true},
+		{name: "probability none", condition: Probabilistic(0), want: false},
+		{name: "and", condition: And(ServiceName("api"), Not(StatusCode(codes.Ok))), want: true},
+		{name: "empty and", condition: And(), want: true},
+		{name: "empty or", condition: Or(), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.condition.Match(tr))
+		})
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+
+	bucket := newTokenBucket(2, func() time.Time { return now })
+
+	assert.True(t, bucket.allow())
+	assert.True(t, bucket.allow())
+	assert.False(t, bucket.allow())
+
+	now = now.Add(500 * time.Millisecond)
+
+	assert.True(t, bucket.allow())
+	assert.False(t, bucket.allow())
+
+	slow := newTokenBucket(0.5, func() time.Time { return now })
+
+	assert.True(t, slow.allow())
+	assert.False(t, slow.allow())
+
+	now = now.Add(2 * time.Second)
+
+	assert.True(t, slow.allow())
+}
+
+func attributeValue(attributes []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
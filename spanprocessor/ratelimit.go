@@ -0,0 +1,175 @@
+package spanprocessor
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	_defaultRateLimitInterval = time.Second
+	_defaultRateLimitMaxKeys  = 10000
+
+	// _overflowKey is shared by traces with new keys when the MaxKeys is reached.
+	_overflowKey = "\x00overflow"
+)
+
+// Reasons of the sampling decision.
+const (
+	reasonSampler = iota
+	reasonError
+	reasonSlow
+	reasonPolicy
+	reasonForceKeep
+	reasonRateLimit
+)
+
+// KeyFunc returns rate limit key of the trace by its root span.
+type KeyFunc func(root tracesdk.ReadOnlySpan) string
+
+// RootSpanName is the default KeyFunc, it returns name of the root span.
+func RootSpanName(root tracesdk.ReadOnlySpan) string {
+	return root.Name()
+}
+
+// RateLimit limits number of kept traces per key.
+type RateLimit struct {
+	// PerSecond is the number of traces kept per second for each key.
+	PerSecond float64
+	// Key returns key of the trace, RootSpanName by default.
+	Key KeyFunc
+	// MinErrors and MinSlow are the numbers of error and slow traces per key per Interval
+	// which are kept even if the limit is exceeded.
+	MinErrors int
+	MinSlow   int
+	// Interval of the MinErrors and MinSlow guarantees, 1s by default.
+	Interval time.Duration
+	// MaxKeys limits number of tracked keys, traces with new keys share one limit after it. 10000 by default.
+	MaxKeys int
+}
+
+// WithRateLimit limits number of kept traces per key. Decisions made by policies are not limited.
+func WithRateLimit(limit RateLimit) Option {
+	return func(p *Sampled) {
+		if limit.PerSecond > 0 {
+			p.limiter = newKeyedLimiter(limit, p.now)
+		}
+	}
+}
+
+type keyState struct {
+	bucket   *tokenBucket
+	window   time.Time
+	errors   int
+	slow     int
+	lastSeen time.Time
+}
+
+// keyedLimiter is a set of token buckets keyed by the trace key.
+type keyedLimiter struct {
+	limit RateLimit
+	idle  time.Duration
+	keys  map[string]*keyState
+	now   func() time.Time
+
+	mu sync.Mutex
+}
+
+func newKeyedLimiter(limit RateLimit, now func() time.Time) *keyedLimiter {
+	if limit.Key == nil {
+		limit.Key = RootSpanName
+	}
+
+	if limit.Interval <= 0 {
+		limit.Interval = _defaultRateLimitInterval
+	}
+
+	if limit.MaxKeys <= 0 {
+		limit.MaxKeys = _defaultRateLimitMaxKeys
+	}
+
+	idle := limit.Interval
+
+	// Bucket of the removed key is recreated full, so keep it until it is refilled.
+	if refill := time.Duration(float64(time.Second) * math.Max(1, limit.PerSecond) / limit.PerSecond); refill > idle {
+		idle = refill
+	}
+
+	return &keyedLimiter{limit: limit, idle: idle, keys: make(map[string]*keyState), now: now}
+}
+
+// allow reports whether the sampled trace with the decision reason can be kept.
+func (l *keyedLimiter) allow(root tracesdk.ReadOnlySpan, reason int) bool {
+	key := l.limit.Key(root)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	state := l.state(key, now)
+
+	if now.Sub(state.window) >= l.limit.Interval {
+		state.window, state.errors, state.slow = now, 0, 0
+	}
+
+	allowed := state.bucket.allow()
+
+	switch reason {
+	case reasonError:
+		allowed = allowed || state.errors < l.limit.MinErrors
+		if allowed {
+			state.errors++
+		}
+	case reasonSlow:
+		allowed = allowed || state.slow < l.limit.MinSlow
+		if allowed {
+			state.slow++
+		}
+	}
+
+	return allowed
+}
+
+// state returns limiter state of the key, must be called under the lock.
+func (l *keyedLimiter) state(key string, now time.Time) *keyState {
+	if state, ok := l.keys[key]; ok {
+		state.lastSeen = now
+
+		return state
+	}
+
+	if len(l.keys) >= l.limit.MaxKeys {
+		l.cleanup(now)
+	}
+
+	if len(l.keys) >= l.limit.MaxKeys {
+		key = _overflowKey
+
+		if state, ok := l.keys[key]; ok {
+			state.lastSeen = now
+
+			return state
+		}
+	}
+
+	state := &keyState{
+		bucket:   newTokenBucket(l.limit.PerSecond, l.now),
+		window:   now,
+		lastSeen: now,
+	}
+
+	l.keys[key] = state
+
+	return state
+}
+
+// cleanup removes idle keys, must be called under the lock.
+func (l *keyedLimiter) cleanup(now time.Time) {
+	for key, state := range l.keys {
+		if now.Sub(state.lastSeen) > l.idle && key != _overflowKey {
+			delete(l.keys, key)
+		}
+	}
+}
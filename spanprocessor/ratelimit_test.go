@@ -0,0 +1,90 @@
+package spanprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestKeyedLimiter(t *testing.T) {
+	var (
+		now     = time.Now()
+		limiter = newKeyedLimiter(RateLimit{PerSecond: 1, MinErrors: 2, MinSlow: 1}, func() time.Time { return now })
+		root    = func(name string) tracesdk.ReadOnlySpan { return tracetest.SpanStub{Name: name}.Snapshot() }
+	)
+
+	assert.True(t, limiter.allow(root("a"), reasonSampler))
+	assert.False(t, limiter.allow(root("a"), reasonSampler))
+	assert.True(t, limiter.allow(root("b"), reasonSampler), "keys have separate limits")
+
+	assert.True(t, limiter.allow(root("a"), reasonError))
+	assert.True(t, limiter.allow(root("a"), reasonError))
+	assert.False(t, limiter.allow(root("a"), reasonError), "min errors reached")
+
+	assert.True(t, limiter.allow(root("a"), reasonSlow))
+	assert.False(t, limiter.allow(root("a"), reasonSlow), "min slow reached")
+
+	now = now.Add(time.Second)
+
+	assert.True(t, limiter.allow(root("a"), reasonError), "token is refilled")
+	assert.True(t, limiter.allow(root("a"), reasonError), "min errors window is reset")
+	assert.False(t, limiter.allow(root("a"), reasonError), "errors kept by the limit count to the min errors")
+}
+
+func TestKeyedLimiter_MaxKeys(t *testing.T) {
+	var (
+		now     = time.Now()
+		limiter = newKeyedLimiter(RateLimit{PerSecond: 1, MaxKeys: 2}, func() time.Time { return now })
+		root    = func(name string) tracesdk.ReadOnlySpan { return tracetest.SpanStub{Name: name}.Snapshot() }
+	)
+
+	assert.True(t, limiter.allow(root("a"), reasonSampler))
+	assert.True(t, limiter.allow(root("b"), reasonSampler))
+	assert.True(t, limiter.allow(root("c"), reasonSampler))
+	assert.False(t, limiter.allow(root("d"), reasonSampler), "new keys share the overflow limit")
+	assert.Len(t, limiter.keys, 3)
+
+	now = now.Add(2 * time.Second)
+
+	assert.True(t, limiter.allow(root("d"), reasonSampler))
+	assert.Len(t, limiter.keys, 2, "idle keys are removed")
+}
+
+func TestSampled_RateLimit(t *testing.T) {
+	var (
+		recorder  = tracetest.NewSpanRecorder()
+		processor = NewSampled(recorder, tracesdk.AlwaysSample(),
+			WithRateLimit(RateLimit{PerSecond: 1, MinErrors: 1, Interval: time.Hour}),
+			WithPolicies(Policy{Name: "keep", Condition: SpanKind(trace.SpanKindServer)}),
+		)
+		tracer = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor)).Tracer("")
+	)
+
+	for i := 0; i < 3; i++ {
+		_, span := tracer.Start(context.Background(), "GET /users")
+		span.End()
+	}
+
+	assert.Len(t, recorder.Ended(), 1)
+
+	for i := 0; i < 3; i++ {
+		_, span := tracer.Start(context.Background(), "GET /users")
+		span.SetStatus(codes.Error, "")
+		span.End()
+	}
+
+	assert.Len(t, recorder.Ended(), 2)
+
+	for i := 0; i < 3; i++ {
+		_, span := tracer.Start(context.Background(), "GET /users", trace.WithSpanKind(trace.SpanKindServer))
+		span.End()
+	}
+
+	assert.Len(t, recorder.Ended(), 5, "policy decisions are not limited")
+}
@@ -0,0 +1,698 @@
+// Package spanprocessor provides the tail-sampling span processor which can be used
+// with any TracerProvider:
+//
+//	provider := tracesdk.NewTracerProvider(
+//		tracesdk.WithSpanProcessor(spanprocessor.NewSampled(
+//			tracesdk.NewBatchSpanProcessor(exporter),
+//			tracesdk.TraceIDRatioBased(0.1),
+//			spanprocessor.WithMaxTraces(10000),
+//			spanprocessor.WithMaxAge(time.Minute),
+//		)),
+//	)
+//
+// The provider must record all spans, so its own sampler should be left by default.
+package spanprocessor
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/loghole/tracing/internal/forcekeep"
+	"github.com/loghole/tracing/internal/metrics"
+)
+
+const (
+	_maxEvictInterval = time.Second
+	_shardsPerCPU     = 4
+)
+
+type wrapper struct {
+	parent    tracesdk.ReadWriteSpan
+	parentCtx context.Context //nolint:containedctx // need context.
+
+	spans map[trace.SpanID]tracesdk.ReadWriteSpan
+
+	started time.Time
+	elem    *list.Element
+
+	sampled bool
+	decided bool
+	policy  string
+	once    sync.Once
+
+	sync.Mutex
+}
+
+func (w *wrapper) isSampled(p *Sampled) bool {
+	w.once.Do(func() {
+		var reason int
+
+		w.sampled, reason = w.decide(p)
+
+		countDecision(w.sampled, reason)
+	})
+
+	w.decided = true
+
+	return w.sampled
+}
+
+// decide returns the sampling decision of the trace and its reason.
+func (w *wrapper) decide(p *Sampled) (bool, int) {
+	if p.forceKeep && w.isForced() {
+		return true, reasonForceKeep
+	}
+
+	if len(p.policies) > 0 && w.checkPolicies(p.policies) {
+		return w.sampled, reasonPolicy
+	}
+
+	sampled, reason := w.checkSampled(p.sampler, p.isError, p.latencyRules)
+
+	if sampled && p.limiter != nil && !p.limiter.allow(w.parent, reason) {
+		return false, reasonRateLimit
+	}
+
+	return sampled, reason
+}
+
+// countDecision updates the sampled traces metric.
+func countDecision(sampled bool, reason int) {
+	switch {
+	case sampled && reason == reasonError:
+		metrics.SampledKeptErrorCounter.Inc()
+	case sampled && reason == reasonSlow:
+		metrics.SampledKeptLatencyCounter.Inc()
+	case sampled && reason == reasonPolicy:
+		metrics.SampledKeptPolicyCounter.Inc()
+	case sampled && reason == reasonForceKeep:
+		metrics.SampledKeptForceKeepCounter.Inc()
+	case sampled:
+		metrics.SampledKeptHeadSamplerCounter.Inc()
+	case reason == reasonPolicy:
+		metrics.SampledDroppedPolicyCounter.Inc()
+	case reason == reasonRateLimit:
+		metrics.SampledDroppedRateLimitCounter.Inc()
+	default:
+		metrics.SampledDroppedHeadSamplerCounter.Inc()
+	}
+}
+
+// isForced reports whether the trace is forced to keep by the remote parent or by any span.
+func (w *wrapper) isForced() bool {
+	if forcekeep.InTraceState(trace.SpanContextFromContext(w.parentCtx).TraceState()) {
+		return true
+	}
+
+	for _, span := range w.spans {
+		for _, attr := range span.Attributes() {
+			if attr.Key == forcekeep.AttributeKey && attr.Value.AsBool() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkPolicies applies the first matched policy, returns false if no policy matches.
+func (w *wrapper) checkPolicies(policies []Policy) bool {
+	t := &Trace{Root: w.parent, Spans: make([]tracesdk.ReadOnlySpan, 0, len(w.spans))}
+
+	for _, span := range w.spans {
+		t.Spans = append(t.Spans, span)
+	}
+
+	for _, policy := range policies {
+		if policy.Condition.Match(t) {
+			w.sampled = !policy.Drop
+			w.policy = policy.Name
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkSampled returns the default sampling decision and its reason.
+func (w *wrapper) checkSampled(sampler tracesdk.Sampler, isError ErrorPredicate, rules []LatencyRule) (bool, int) {
+	for _, span := range w.spans {
+		if isError(span) {
+			return true, reasonError
+		}
+	}
+
+	if len(rules) > 0 && w.isSlow(rules) {
+		return true, reasonSlow
+	}
+
+	result := sampler.ShouldSample(tracesdk.SamplingParameters{
+		ParentContext: w.parentCtx,
+		TraceID:       w.parent.SpanContext().TraceID(),
+		Name:          w.parent.Name(),
+		Kind:          w.parent.SpanKind(),
+		Attributes:    w.parent.Attributes(),
+		Links:         nil, // skip links, because they are not used in samplers.
+	})
+
+	return result.Decision == tracesdk.RecordAndSample, reasonSampler
+}
+
+// isSlow reports whether any ended span of the trace exceeds the latency rule.
+func (w *wrapper) isSlow(rules []LatencyRule) bool {
+	for _, span := range w.spans {
+		if span.EndTime().IsZero() {
+			continue
+		}
+
+		duration := span.EndTime().Sub(span.StartTime())
+
+		for _, rule := range rules {
+			if duration >= rule.Threshold && rule.match(span) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// LatencyRule keeps traces with a span which lasts at least the Threshold.
+type LatencyRule struct {
+	// Name matches the span name, empty value matches any span.
+	Name string
+	// Route matches the http.route or the rpc.method attribute, empty value matches any span.
+	Route     string
+	Threshold time.Duration
+}
+
+func (r *LatencyRule) match(span tracesdk.ReadWriteSpan) bool {
+	if r.Name != "" && r.Name != span.Name() {
+		return false
+	}
+
+	if r.Route == "" {
+		return true
+	}
+
+	for _, attr := range span.Attributes() {
+		if (attr.Key == semconv.HTTPRouteKey || attr.Key == semconv.RPCMethodKey) && attr.Value.AsString() == r.Route {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Option configures Sampled.
+type Option func(p *Sampled)
+
+// WithMaxTraces limits number of buffered traces, the oldest trace of the shard is force-decided
+// and flushed when the limit is reached. Zero means no limit.
+//
+// The limit is shared by all shards, the trace of another shard is evicted if the shard of the new
+// trace is empty. Concurrent starts can exceed the limit by the number of concurrent callers.
+func WithMaxTraces(n int) Option {
+	return func(p *Sampled) {
+		p.maxTraces = n
+	}
+}
+
+// WithMaxSpansPerTrace limits number of buffered spans of one trace, spans over the limit are dropped.
+// Zero means no limit.
+func WithMaxSpansPerTrace(n int) Option {
+	return func(p *Sampled) {
+		p.maxSpans = n
+	}
+}
+
+// WithMaxAge sets max time since the first span of the trace was started, after it
+// incomplete trace is force-decided and flushed. Zero means no limit.
+func WithMaxAge(d time.Duration) Option {
+	return func(p *Sampled) {
+		p.maxAge = d
+	}
+}
+
+// WithLatencyRules keeps traces with slow spans regardless of the sampler decision.
+func WithLatencyRules(rules ...LatencyRule) Option {
+	return func(p *Sampled) {
+		p.latencyRules = append(p.latencyRules, rules...)
+	}
+}
+
+// WithForceKeep keeps traces which are forced to keep by other services or by spans of the trace,
+// see tracing.ForceKeep. Spans with errors by the ErrorPredicate ask other services to keep the trace.
+// Forced traces are not rate limited.
+func WithForceKeep() Option {
+	return func(p *Sampled) {
+		p.forceKeep = true
+	}
+}
+
+// WithShards sets number of shards, traces are distributed between shards by the trace id
+// and each shard has its own lock. Four shards per CPU by default.
+func WithShards(n int) Option {
+	return func(p *Sampled) {
+		if n > 0 {
+			p.shardsCount = n
+		}
+	}
+}
+
+// shard keeps part of buffered traces.
+type shard struct {
+	traces map[trace.TraceID]*wrapper
+	order  *list.List    // traces by start time, the oldest first.
+	total  *atomic.Int64 // buffered traces of all shards.
+	mu     sync.Mutex
+}
+
+// Sampled buffers spans of the trace until the first span of the trace in this process
+// is ended, then makes sampling decision for the whole trace. Sampled spans are sent to
+// the processor outside of the lock.
+type Sampled struct {
+	processor tracesdk.SpanProcessor
+	sampler   tracesdk.Sampler
+
+	maxTraces int
+	traces    atomic.Int64
+	maxSpans  int
+	maxAge    time.Duration
+	now       func() time.Time
+
+	latencyRules []LatencyRule
+	policies     []Policy
+	limiter      *keyedLimiter
+	partial      *PartialFlush
+	isError      ErrorPredicate
+	forceKeep    bool
+
+	shardsCount int
+	shards      []*shard
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewSampled returns the processor which passes spans of sampled traces to the processor.
+// The sampler decides for traces without errors, slow spans and matched policies.
+func NewSampled(
+	processor tracesdk.SpanProcessor,
+	sampler tracesdk.Sampler,
+	options ...Option,
+) *Sampled {
+	p := &Sampled{
+		processor:   processor,
+		sampler:     sampler,
+		now:         time.Now,
+		isError:     DefaultErrorPredicate,
+		shardsCount: runtime.GOMAXPROCS(0) * _shardsPerCPU,
+		done:        make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	p.shards = make([]*shard, p.shardsCount)
+
+	for idx := range p.shards {
+		p.shards[idx] = &shard{traces: make(map[trace.TraceID]*wrapper), order: list.New(), total: &p.traces}
+	}
+
+	if p.maxAge > 0 {
+		p.wg.Add(1)
+
+		go p.evictLoop()
+	}
+
+	return p
+}
+
+func (p *Sampled) OnStart(parent context.Context, span tracesdk.ReadWriteSpan) {
+	if !span.IsRecording() {
+		return
+	}
+
+	var (
+		spanCtx = span.SpanContext()
+		traceID = spanCtx.TraceID()
+		spanID  = spanCtx.SpanID()
+		s       = p.shard(traceID)
+	)
+
+	s.mu.Lock()
+
+	wr, ok := s.traces[traceID]
+	if ok {
+		switch {
+		case wr.decided && !wr.sampled:
+			// Spans of the partially flushed trace which is not sampled are not buffered.
+		case p.maxSpans > 0 && len(wr.spans) >= p.maxSpans:
+			metrics.SampledDroppedSpansMaxSpansCounter.Inc()
+		default:
+			wr.spans[spanID] = span
+
+			metrics.SampledBufferedSpans.Inc()
+		}
+
+		s.mu.Unlock()
+
+		return
+	}
+
+	var (
+		spans      []endedSpan
+		evictOther bool
+	)
+
+	if p.maxTraces > 0 && p.traces.Load() >= int64(p.maxTraces) {
+		if s.order.Len() > 0 {
+			spans = p.evictOldest(s, metrics.SampledEvictedTracesMaxTracesCounter)
+		} else {
+			evictOther = true
+		}
+	}
+
+	wr = &wrapper{
+		parent:    span,
+		parentCtx: parent,
+		spans:     map[trace.SpanID]tracesdk.ReadWriteSpan{spanID: span},
+		started:   p.now(),
+	}
+
+	wr.elem = s.order.PushBack(wr)
+
+	s.traces[traceID] = wr
+	s.total.Add(1)
+
+	s.mu.Unlock()
+
+	metrics.SampledBufferedTraces.Inc()
+	metrics.SampledBufferedSpans.Inc()
+
+	if evictOther {
+		spans = p.evictOverLimit(s)
+	}
+
+	p.send(spans)
+}
+
+func (p *Sampled) OnEnd(span tracesdk.ReadOnlySpan) {
+	var (
+		spanCtx = span.SpanContext()
+		traceID = spanCtx.TraceID()
+		s       = p.shard(traceID)
+	)
+
+	s.mu.Lock()
+
+	wr, ok := s.traces[traceID]
+	if !ok {
+		s.mu.Unlock()
+
+		return
+	}
+
+	if p.forceKeep && p.isError(span) {
+		// Ask other services to keep the trace, tracehttp and tracegrpc send the flag of the request.
+		forcekeep.SetFlag(wr.parentCtx)
+	}
+
+	if !wr.parent.SpanContext().Equal(spanCtx) && wr.parent.IsRecording() &&
+		(p.partial == nil || !p.shouldFlush(wr, span)) {
+		s.mu.Unlock()
+
+		return
+	}
+
+	spans := p.finishWrapper(s, wr)
+
+	s.mu.Unlock()
+
+	p.send(spans)
+}
+
+func (p *Sampled) Shutdown(ctx context.Context) error {
+	p.once.Do(func() { close(p.done) })
+	p.wg.Wait()
+
+	p.flush() //nolint:contextcheck // not need.
+
+	return p.processor.Shutdown(ctx)
+}
+
+func (p *Sampled) ForceFlush(ctx context.Context) error {
+	p.flush() //nolint:contextcheck // not need.
+
+	return p.processor.ForceFlush(ctx)
+}
+
+func (p *Sampled) flush() {
+	defer prometheus.NewTimer(metrics.SampledFlushDurationForceFlush).ObserveDuration()
+
+	for _, s := range p.shards {
+		var spans []endedSpan
+
+		s.mu.Lock()
+
+		for _, wr := range s.traces {
+			spans = append(spans, p.finishWrapper(s, wr)...) //nolint:contextcheck // not need.
+		}
+
+		s.mu.Unlock()
+
+		p.send(spans)
+	}
+}
+
+// shard returns shard of the trace, trace ids are random so low bytes are distributed evenly.
+func (p *Sampled) shard(traceID trace.TraceID) *shard {
+	if len(p.shards) == 1 {
+		return p.shards[0]
+	}
+
+	return p.shards[binary.LittleEndian.Uint64(traceID[8:])%uint64(len(p.shards))]
+}
+
+// len returns number of buffered traces.
+func (p *Sampled) len() int {
+	var count int
+
+	for _, s := range p.shards {
+		s.mu.Lock()
+		count += len(s.traces)
+		s.mu.Unlock()
+	}
+
+	return count
+}
+
+// endedSpan is the ended span of the sampled trace with the local root of the trace.
+type endedSpan struct {
+	span tracesdk.ReadWriteSpan
+	root tracesdk.ReadWriteSpan
+}
+
+// send passes spans to the processor, the parent context of OnStart contains the local root
+// of the trace, so the processor can make decisions for the whole trace, see Fanout.
+func (p *Sampled) send(spans []endedSpan) {
+	for _, ended := range spans {
+		p.processor.OnStart(trace.ContextWithSpan(context.Background(), ended.root), ended.span)
+		p.processor.OnEnd(ended.span)
+	}
+}
+
+// finishWrapper makes sampling decision and returns ended spans of the sampled trace,
+// must be called under the shard lock.
+func (p *Sampled) finishWrapper(s *shard, wr *wrapper) []endedSpan {
+	traceID := wr.parent.SpanContext().TraceID()
+
+	if !wr.isSampled(p) {
+		if p.partial != nil && wr.parent.IsRecording() {
+			// Keep the partially flushed trace until the root span ends to drop its next spans.
+			for spanID, span := range wr.spans {
+				if !span.EndTime().IsZero() {
+					delete(wr.spans, spanID)
+
+					metrics.SampledBufferedSpans.Dec()
+				}
+			}
+
+			return nil
+		}
+
+		s.remove(traceID, wr)
+
+		return nil
+	}
+
+	var (
+		spans []endedSpan
+		root  tracesdk.ReadWriteSpan
+	)
+
+	for _, span := range wr.spans {
+		if span.EndTime().IsZero() {
+			continue
+		}
+
+		delete(wr.spans, span.SpanContext().SpanID())
+
+		metrics.SampledBufferedSpans.Dec()
+
+		if span == wr.parent {
+			root = span
+
+			continue
+		}
+
+		spans = append(spans, endedSpan{span: span, root: wr.parent})
+	}
+
+	// The local root is sent last, as it usually ends after its children.
+	if root != nil {
+		if wr.policy != "" {
+			root = &policySpan{ReadWriteSpan: root, policy: wr.policy}
+		}
+
+		spans = append(spans, endedSpan{span: root, root: wr.parent})
+	}
+
+	if len(wr.spans) == 0 {
+		s.remove(traceID, wr)
+	}
+
+	return spans
+}
+
+// remove deletes the trace from the shard, must be called under the shard lock.
+func (s *shard) remove(traceID trace.TraceID, wr *wrapper) {
+	delete(s.traces, traceID)
+	s.total.Add(-1)
+
+	metrics.SampledBufferedTraces.Dec()
+	metrics.SampledBufferedSpans.Sub(float64(len(wr.spans)))
+
+	if wr.elem != nil {
+		s.order.Remove(wr.elem)
+		wr.elem = nil
+	}
+}
+
+// evict force-decides the trace, returns ended spans if the trace is sampled and drops
+// the rest of spans, must be called under the shard lock.
+func (p *Sampled) evict(s *shard, wr *wrapper, counter prometheus.Counter) []endedSpan {
+	counter.Inc()
+
+	spans := p.finishWrapper(s, wr)
+
+	if _, ok := s.traces[wr.parent.SpanContext().TraceID()]; !ok {
+		return spans
+	}
+
+	metrics.SampledDroppedSpansEvictedCounter.Add(float64(len(wr.spans)))
+
+	s.remove(wr.parent.SpanContext().TraceID(), wr)
+
+	return spans
+}
+
+// evictOldest evicts the oldest trace of the shard, must be called under the shard lock.
+func (p *Sampled) evictOldest(s *shard, counter prometheus.Counter) []endedSpan {
+	if front := s.order.Front(); front != nil {
+		return p.evict(s, front.Value.(*wrapper), counter) //nolint:forcetypeassert // list contains only wrappers.
+	}
+
+	return nil
+}
+
+// evictOverLimit evicts the oldest trace of the first not empty shard other than the current one
+// if the max traces limit is still exceeded, must be called outside of the lock.
+func (p *Sampled) evictOverLimit(current *shard) []endedSpan {
+	for _, s := range p.shards {
+		if s == current {
+			continue
+		}
+
+		s.mu.Lock()
+
+		if p.traces.Load() <= int64(p.maxTraces) {
+			s.mu.Unlock()
+
+			return nil
+		}
+
+		if s.order.Len() > 0 {
+			spans := p.evictOldest(s, metrics.SampledEvictedTracesMaxTracesCounter)
+
+			s.mu.Unlock()
+
+			return spans
+		}
+
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// evictExpired evicts traces which were started before the max age.
+func (p *Sampled) evictExpired() {
+	defer prometheus.NewTimer(metrics.SampledFlushDurationMaxAge).ObserveDuration()
+
+	deadline := p.now().Add(-p.maxAge)
+
+	for _, s := range p.shards {
+		var spans []endedSpan
+
+		s.mu.Lock()
+
+		for front := s.order.Front(); front != nil; front = s.order.Front() {
+			wr := front.Value.(*wrapper) //nolint:forcetypeassert // list contains only wrappers.
+			if wr.started.After(deadline) {
+				break
+			}
+
+			spans = append(spans, p.evict(s, wr, metrics.SampledEvictedTracesMaxAgeCounter)...)
+		}
+
+		s.mu.Unlock()
+
+		p.send(spans)
+	}
+}
+
+func (p *Sampled) evictLoop() {
+	defer p.wg.Done()
+
+	interval := p.maxAge / 2
+	if interval > _maxEvictInterval {
+		interval = _maxEvictInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.evictExpired()
+		}
+	}
+}
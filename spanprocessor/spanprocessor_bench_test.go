@@ -2,7 +2,9 @@ package spanprocessor
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
+	"runtime"
 	"testing"
 
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
@@ -48,6 +50,26 @@ func BenchmarkSpanProcessor(b *testing.B) {
 	})
 }
 
+// BenchmarkSampled_Shards compares parallel throughput of the single lock with the sharded one,
+// e.g. go test -bench Shards -cpu 1,8,32.
+func BenchmarkSampled_Shards(b *testing.B) {
+	for _, shards := range []int{1, 4, 16, runtime.GOMAXPROCS(0) * _shardsPerCPU} {
+		for _, sampler := range []tracesdk.Sampler{tracesdk.NeverSample(), tracesdk.AlwaysSample()} {
+			b.Run(fmt.Sprintf("shards=%d/%s", shards, sampler.Description()), func(b *testing.B) {
+				var (
+					processor = NewSampled(NoopSpanProcessor{}, sampler, WithShards(shards))
+					tracer    = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor)).Tracer("")
+				)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				b.RunParallel(parallelBenchmark(tracer))
+			})
+		}
+	}
+}
+
 func parallelBenchmark(tracer trace.Tracer) func(pb *testing.PB) {
 	ctx := context.Background()
 
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -15,6 +16,8 @@ import (
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/loghole/tracing/internal/forcekeep"
+	"github.com/loghole/tracing/internal/metrics"
 	"github.com/loghole/tracing/mocks"
 )
 
@@ -229,7 +232,7 @@ func TestSampled_Shutdown(t *testing.T) {
 				processor.EXPECT().OnEnd(gomock.Any())
 
 				sampled := NewSampled(processor, tracesdk.AlwaysSample())
-				sampled.traces = map[trace.TraceID]*wrapper{
+				sampled.shard(trace.TraceID{1}).traces = map[trace.TraceID]*wrapper{
 					trace.TraceID{1}: {
 						parent:    &NoopSpan{},
 						parentCtx: context.Background(),
@@ -274,7 +277,7 @@ func TestSampled_ForceFlush(t *testing.T) {
 				processor.EXPECT().OnEnd(gomock.Any())
 
 				sampled := NewSampled(processor, tracesdk.AlwaysSample())
-				sampled.traces = map[trace.TraceID]*wrapper{
+				sampled.shard(trace.TraceID{1}).traces = map[trace.TraceID]*wrapper{
 					trace.TraceID{1}: {
 						parent:    &NoopSpan{},
 						parentCtx: context.Background(),
@@ -309,7 +312,7 @@ func TestSampled_BaseWork(t *testing.T) {
 		processor.OnEnd(span)
 	}
 
-	assert.Equal(t, map[trace.TraceID]*wrapper{}, processor.traces)
+	assert.Zero(t, processor.len())
 }
 
 func TestSampled_SendSpan(t *testing.T) {
@@ -473,6 +476,213 @@ func TestSampled_SendSpan(t *testing.T) {
 	}
 }
 
+func TestSampled_Limits(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		run     func(t *testing.T, processor *Sampled, tracer trace.Tracer)
+		want    []string
+	}{
+		{
+			name:    "max traces",
+			options: []Option{WithMaxTraces(2), WithShards(1)},
+			run: func(t *testing.T, processor *Sampled, tracer trace.Tracer) {
+				ctx, _ := tracer.Start(context.Background(), "root-1")
+				_, child := tracer.Start(ctx, "child-1")
+				child.End()
+
+				_, _ = tracer.Start(context.Background(), "root-2")
+				_, _ = tracer.Start(context.Background(), "root-3")
+
+				assert.Equal(t, 2, processor.len())
+			},
+			want: []string{"child-1"},
+		},
+		{
+			name:    "max traces sharded",
+			options: []Option{WithMaxTraces(8), WithShards(4)},
+			run: func(t *testing.T, processor *Sampled, tracer trace.Tracer) {
+				for i := 0; i < 100; i++ {
+					_, _ = tracer.Start(context.Background(), "root")
+				}
+
+				assert.Equal(t, 8, processor.len())
+				assert.Equal(t, int64(8), processor.traces.Load())
+			},
+			want: []string{},
+		},
+		{
+			name:    "max traces with more shards than traces",
+			options: []Option{WithMaxTraces(10), WithShards(64)},
+			run: func(t *testing.T, processor *Sampled, tracer trace.Tracer) {
+				for i := 0; i < 10; i++ {
+					ctx, _ := tracer.Start(context.Background(), "root")
+					_, child := tracer.Start(ctx, "child")
+					child.End()
+				}
+
+				// Nothing is evicted before the limit is reached.
+				assert.Equal(t, 10, processor.len())
+
+				_, _ = tracer.Start(context.Background(), "root")
+
+				assert.Equal(t, 10, processor.len())
+			},
+			want: []string{"child"},
+		},
+		{
+			name:    "max spans per trace",
+			options: []Option{WithMaxSpansPerTrace(2)},
+			run: func(t *testing.T, processor *Sampled, tracer trace.Tracer) {
+				ctx, root := tracer.Start(context.Background(), "root")
+
+				for _, name := range []string{"child-1", "child-2"} {
+					_, child := tracer.Start(ctx, name)
+					child.End()
+				}
+
+				root.End()
+
+				assert.Zero(t, processor.len())
+			},
+			want: []string{"child-1", "root"},
+		},
+		{
+			name:    "max age",
+			options: []Option{WithMaxAge(time.Minute), WithShards(1)},
+			run: func(t *testing.T, processor *Sampled, tracer trace.Tracer) {
+				ctx, _ := tracer.Start(context.Background(), "old")
+				_, child := tracer.Start(ctx, "old-child")
+				child.End()
+
+				processor.now = func() time.Time { return time.Now().Add(30 * time.Second) }
+
+				_, _ = tracer.Start(context.Background(), "new")
+
+				processor.now = func() time.Time { return time.Now().Add(75 * time.Second) }
+				processor.evictExpired()
+
+				assert.Equal(t, 1, processor.len())
+				assert.Equal(t, 1, processor.shards[0].order.Len())
+			},
+			want: []string{"old-child"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				recorder  = tracetest.NewSpanRecorder()
+				processor = NewSampled(recorder, tracesdk.AlwaysSample(), tt.options...)
+				tracer    = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor)).Tracer("")
+			)
+
+			defer processor.Shutdown(context.Background())
+
+			tt.run(t, processor, tracer)
+
+			names := make([]string, 0, len(recorder.Ended()))
+
+			for _, span := range recorder.Ended() {
+				names = append(names, span.Name())
+			}
+
+			assert.ElementsMatch(t, tt.want, names)
+		})
+	}
+}
+
+func TestSampled_LatencyRules(t *testing.T) {
+	type span struct {
+		name     string
+		route    string
+		duration time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		rules []LatencyRule
+		child span
+		want  int
+	}{
+		{
+			name:  "any span slow",
+			rules: []LatencyRule{{Threshold: time.Second}},
+			child: span{name: "child", duration: 2 * time.Second},
+			want:  2,
+		},
+		{
+			name:  "fast",
+			rules: []LatencyRule{{Threshold: time.Second}},
+			child: span{name: "child", duration: time.Millisecond},
+			want:  0,
+		},
+		{
+			name:  "by name",
+			rules: []LatencyRule{{Name: "db.query", Threshold: 100 * time.Millisecond}},
+			child: span{name: "db.query", duration: 200 * time.Millisecond},
+			want:  2,
+		},
+		{
+			name:  "other name",
+			rules: []LatencyRule{{Name: "db.query", Threshold: 100 * time.Millisecond}},
+			child: span{name: "cache.get", duration: 200 * time.Millisecond},
+			want:  0,
+		},
+		{
+			name:  "by route",
+			rules: []LatencyRule{{Route: "/users/{id}", Threshold: 100 * time.Millisecond}},
+			child: span{name: "GET", route: "/users/{id}", duration: 200 * time.Millisecond},
+			want:  2,
+		},
+		{
+			name: "route threshold not exceeded",
+			rules: []LatencyRule{
+				{Route: "/users/{id}", Threshold: time.Second},
+				{Route: "/health", Threshold: 100 * time.Millisecond},
+			},
+			child: span{name: "GET", route: "/users/{id}", duration: 200 * time.Millisecond},
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				recorder  = tracetest.NewSpanRecorder()
+				processor = NewSampled(recorder, tracesdk.NeverSample(), WithLatencyRules(tt.rules...))
+				tracer    = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor)).Tracer("")
+				start     = time.Now()
+			)
+
+			ctx, root := tracer.Start(context.Background(), "root", trace.WithTimestamp(start))
+
+			_, child := tracer.Start(ctx, tt.child.name, trace.WithTimestamp(start),
+				trace.WithAttributes(attribute.String("http.route", tt.child.route)))
+			child.End(trace.WithTimestamp(start.Add(tt.child.duration)))
+
+			root.End(trace.WithTimestamp(start.Add(tt.child.duration)))
+
+			assert.Len(t, recorder.Ended(), tt.want)
+		})
+	}
+}
+
+func TestSampled_MaxAgeLoop(t *testing.T) {
+	var (
+		recorder  = tracetest.NewSpanRecorder()
+		processor = NewSampled(recorder, tracesdk.AlwaysSample(), WithMaxAge(10*time.Millisecond))
+		tracer    = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor)).Tracer("")
+	)
+
+	ctx, _ := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+
+	assert.Eventually(t, func() bool { return len(recorder.Ended()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.NoError(t, processor.Shutdown(context.Background()))
+}
+
 type NoopSpan struct {
 	tracesdk.ReadWriteSpan
 	context trace.SpanContext
@@ -598,3 +808,133 @@ func BenchmarkSampled(b *testing.B) {
 		})
 	})
 }
+
+func TestSampled_ForceKeep(t *testing.T) {
+	var (
+		recorder  = tracetest.NewSpanRecorder()
+		processor = NewSampled(recorder, tracesdk.NeverSample(), WithForceKeep(),
+			WithRateLimit(RateLimit{PerSecond: 1}), WithPolicies(Policy{Name: "drop", Condition: And(), Drop: true}))
+		tracer = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor)).Tracer("")
+	)
+
+	_, span := tracer.Start(context.Background(), "not forced")
+	span.End()
+
+	assert.Empty(t, recorder.Ended())
+
+	ctx, root := tracer.Start(context.Background(), "by attribute")
+	_, child := tracer.Start(ctx, "client", trace.WithAttributes(forcekeep.AttributeKey.Bool(true)))
+	child.End()
+	root.End()
+
+	assert.Len(t, recorder.Ended(), 2)
+
+	state, _ := trace.TraceState{}.Insert(forcekeep.TraceStateKey, forcekeep.TraceStateValue)
+
+	remote := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		TraceState: state,
+	}))
+
+	for i := 0; i < 3; i++ {
+		_, span = tracer.Start(remote, "by tracestate")
+		span.End()
+	}
+
+	assert.Len(t, recorder.Ended(), 5, "forced traces are not rate limited")
+}
+
+func TestSampled_ForceKeepOnError(t *testing.T) {
+	forcekeep.SetEnabled(true)
+	defer forcekeep.SetEnabled(false)
+
+	tests := []struct {
+		name    string
+		options []Option
+		status  codes.Code
+		want    bool
+	}{
+		{
+			name:    "error",
+			options: []Option{WithForceKeep()},
+			status:  codes.Error,
+			want:    true,
+		},
+		{
+			name:    "ok",
+			options: []Option{WithForceKeep()},
+			status:  codes.Ok,
+			want:    false,
+		},
+		{
+			name:    "error predicate",
+			options: []Option{WithForceKeep(), WithErrorPredicate(func(tracesdk.ReadOnlySpan) bool { return false })},
+			status:  codes.Error,
+			want:    false,
+		},
+		{
+			name:   "force keep disabled",
+			status: codes.Error,
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				processor = NewSampled(tracetest.NewSpanRecorder(), tracesdk.NeverSample(), tt.options...)
+				tracer    = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor)).Tracer("")
+			)
+
+			ctx, root := tracer.Start(forcekeep.WithFlag(context.Background()), "server")
+			defer root.End()
+
+			_, child := tracer.Start(ctx, "db")
+			child.SetStatus(tt.status, "")
+			child.End()
+
+			assert.Equal(t, tt.want, forcekeep.IsSet(ctx))
+		})
+	}
+}
+
+func TestSampled_Metrics(t *testing.T) {
+	var (
+		recorder  = tracetest.NewSpanRecorder()
+		processor = NewSampled(recorder, tracesdk.NeverSample(), WithPolicies(Policy{Name: "drop", Condition: SpanKind(trace.SpanKindServer), Drop: true}))
+		tracer    = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor)).Tracer("")
+
+		traces        = testutil.ToFloat64(metrics.SampledBufferedTraces)
+		spans         = testutil.ToFloat64(metrics.SampledBufferedSpans)
+		keptErrors    = testutil.ToFloat64(metrics.SampledKeptErrorCounter)
+		droppedHead   = testutil.ToFloat64(metrics.SampledDroppedHeadSamplerCounter)
+		droppedPolicy = testutil.ToFloat64(metrics.SampledDroppedPolicyCounter)
+	)
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+
+	assert.Equal(t, traces+1, testutil.ToFloat64(metrics.SampledBufferedTraces))
+	assert.Equal(t, spans+2, testutil.ToFloat64(metrics.SampledBufferedSpans))
+
+	child.SetStatus(codes.Error, "")
+	child.End()
+	root.End()
+
+	assert.Equal(t, traces, testutil.ToFloat64(metrics.SampledBufferedTraces))
+	assert.Equal(t, spans, testutil.ToFloat64(metrics.SampledBufferedSpans))
+	assert.Equal(t, keptErrors+1, testutil.ToFloat64(metrics.SampledKeptErrorCounter))
+
+	_, span := tracer.Start(context.Background(), "not sampled")
+	span.End()
+
+	assert.Equal(t, droppedHead+1, testutil.ToFloat64(metrics.SampledDroppedHeadSamplerCounter))
+
+	_, span = tracer.Start(context.Background(), "server", trace.WithSpanKind(trace.SpanKindServer))
+	span.End()
+
+	assert.Equal(t, droppedPolicy+1, testutil.ToFloat64(metrics.SampledDroppedPolicyCounter))
+	assert.Equal(t, spans, testutil.ToFloat64(metrics.SampledBufferedSpans))
+}
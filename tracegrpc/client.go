@@ -2,8 +2,14 @@ package tracegrpc
 
 import (
 	"context"
-	"net/http"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 	"go.opentelemetry.io/otel/trace"
@@ -12,6 +18,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/loghole/tracing"
+	"github.com/loghole/tracing/internal/forcekeep"
 	"github.com/loghole/tracing/internal/metrics"
 )
 
@@ -32,7 +39,7 @@ func DialContext(
 		grpc.WithDisableServiceConfig(),
 		grpc.WithDefaultServiceConfig(loadBalancing),
 		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor(tracer)),
-		grpc.WithChainStreamInterceptor(StreamClientInterceptor()),
+		grpc.WithChainStreamInterceptor(StreamClientInterceptorWithTracer(tracer)),
 	)
 
 	return grpc.DialContext(ctx, target, opts...)
@@ -51,16 +58,16 @@ func UnaryClientInterceptor(tracer trace.Tracer) grpc.UnaryClientInterceptor {
 		ctx, span := tracer.Start(ctx, defaultNameFunc(method), trace.WithSpanKind(trace.SpanKindClient))
 		defer span.End()
 
-		md, ok := metadata.FromOutgoingContext(ctx)
-		if !ok {
-			md = metadata.New(nil)
-		} else {
-			md = md.Copy()
-		}
+		var header, trailer metadata.MD
+
+		opts = append(opts, grpc.Header(&header), grpc.Trailer(&trailer))
 
-		tracing.InjectHeaders(ctx, http.Header(md))
+		err := invoker(injectMetadata(ctx), method, req, reply, cc, opts...)
+
+		if hasForceKeep(header) || hasForceKeep(trailer) {
+			forcekeep.Set(ctx)
+		}
 
-		err := invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
 		if err != nil {
 			metrics.GRPCFailedOutputReqCounter.Inc()
 		} else {
@@ -68,11 +75,14 @@ func UnaryClientInterceptor(tracer trace.Tracer) grpc.UnaryClientInterceptor {
 		}
 
 		setAttributes(span, method, err)
+		span.SetAttributes(peerAttributes(cc.Target())...)
 
 		return err
 	}
 }
 
+// StreamClientInterceptor counts client streams, it does not trace them.
+// Use StreamClientInterceptorWithTracer to trace streams.
 func StreamClientInterceptor() grpc.StreamClientInterceptor {
 	return func(
 		ctx context.Context,
@@ -93,6 +103,149 @@ func StreamClientInterceptor() grpc.StreamClientInterceptor {
 	}
 }
 
+// StreamClientInterceptorWithTracer returns trace grpc stream interceptor. The span is ended when
+// the stream is finished: RecvMsg returns an error or io.EOF, the only response of the not server
+// streaming method is received or the stream context is done.
+func StreamClientInterceptorWithTracer(tracer trace.Tracer) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		ctx, span := tracer.Start(ctx, defaultNameFunc(method), trace.WithSpanKind(trace.SpanKindClient))
+
+		span.SetAttributes(peerAttributes(cc.Target())...)
+
+		stream, err := streamer(injectMetadata(ctx), desc, cc, method, opts...)
+		if err != nil {
+			metrics.GRPCFailedOutputReqCounter.Inc()
+
+			setAttributes(span, method, err)
+			span.End()
+
+			return stream, err
+		}
+
+		metrics.GRPCSuccessOutputReqCounter.Inc()
+
+		tracingStream := &tracingClientStream{
+			ClientStream: stream,
+			desc:         desc,
+			done:         make(chan struct{}),
+			finish: func(stream grpc.ClientStream, err error) {
+				header, _ := stream.Header()
+
+				if hasForceKeep(header) || hasForceKeep(stream.Trailer()) {
+					forcekeep.Set(ctx)
+				}
+
+				setAttributes(span, method, err)
+				span.End()
+			},
+		}
+
+		// Abandoned streams are finished by the context.
+		go func() {
+			select {
+			case <-ctx.Done():
+				tracingStream.end(status.FromContextError(ctx.Err()).Err())
+			case <-tracingStream.done:
+			}
+		}()
+
+		return tracingStream, nil
+	}
+}
+
+type tracingClientStream struct {
+	grpc.ClientStream
+	desc   *grpc.StreamDesc
+	done   chan struct{}
+	finish func(stream grpc.ClientStream, err error)
+	once   sync.Once
+}
+
+func (s *tracingClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	switch {
+	case errors.Is(err, io.EOF):
+		s.end(nil)
+	case err != nil:
+		s.end(err)
+	case !s.desc.ServerStreams:
+		s.end(nil)
+	}
+
+	return err
+}
+
+func (s *tracingClientStream) end(err error) {
+	s.once.Do(func() {
+		close(s.done)
+		s.finish(s.ClientStream, err)
+	})
+}
+
+// injectMetadata returns the context with the trace context in the outgoing metadata.
+func injectMetadata(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.New(nil)
+	} else {
+		md = md.Copy()
+	}
+
+	tracing.Inject(ctx, metadataCarrier(md))
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// hasForceKeep reports whether the server asks to keep the trace.
+func hasForceKeep(md metadata.MD) bool {
+	for _, value := range md.Get(forcekeep.Header) {
+		if forcekeep.HeaderValue(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setForceKeep asks the client to keep the trace if this service keeps it.
+func setForceKeep(ctx context.Context, setTrailer func(md metadata.MD)) {
+	if forcekeep.IsSet(ctx) {
+		setTrailer(metadata.Pairs(forcekeep.Header, "1"))
+	}
+}
+
+// peerAttributes returns remote endpoint attributes, they are used by exporters like zipkin.
+func peerAttributes(target string) []attribute.KeyValue {
+	if idx := strings.LastIndex(target, "/"); idx >= 0 {
+		target = target[idx+1:]
+	}
+
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		host = target
+	}
+
+	if host == "" {
+		return nil
+	}
+
+	attributes := []attribute.KeyValue{semconv.NetPeerNameKey.String(host)}
+
+	if port, err := strconv.Atoi(port); err == nil {
+		attributes = append(attributes, semconv.NetPeerPortKey.Int(port))
+	}
+
+	return attributes
+}
+
 func setAttributes(span trace.Span, method string, err error) {
 	st, _ := status.FromError(err)
 
@@ -0,0 +1,258 @@
+package tracegrpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/loghole/tracing"
+	"github.com/loghole/tracing/internal/forcekeep"
+	"github.com/loghole/tracing/mocks"
+)
+
+func TestUnaryClientInterceptor_Baggage(t *testing.T) {
+	tests := []struct {
+		name        string
+		propagators []string
+		want        string
+	}{
+		{
+			name:        "baggage propagator",
+			propagators: []string{"tracecontext", "baggage"},
+			want:        "42",
+		},
+		{
+			name:        "default propagator",
+			propagators: []string{"tracecontext"},
+			want:        "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setPropagator(t, tt.propagators...)
+
+			var (
+				tracer, _ = mocks.NewTracerWithRecorder()
+				tenant    string
+			)
+
+			conn := newTestConn(t, tracer, func(
+				ctx context.Context,
+				req interface{},
+				info *grpc.UnaryServerInfo,
+				handler grpc.UnaryHandler,
+			) (interface{}, error) {
+				tenant = tracing.BaggageValue(ctx, "tenant.id")
+
+				return handler(ctx, req)
+			})
+
+			ctx, err := tracing.WithBaggage(context.Background(), "tenant.id", "42")
+			require.NoError(t, err)
+
+			_, err = grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, tenant)
+		})
+	}
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	enableForceKeep(t)
+
+	tracer, recorder := mocks.NewTracerWithRecorder()
+
+	conn := newStreamConn(t, tracer, func(_ interface{}, stream grpc.ServerStream) error {
+		tracing.ForceKeep(stream.Context())
+
+		for idx := 0; idx < 2; idx++ {
+			if err := stream.SendMsg(&grpc_health_v1.HealthCheckResponse{}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	ctx, parent := tracer.Start(forcekeep.WithFlag(context.Background()), "parent")
+	defer parent.End()
+
+	require.False(t, forcekeep.IsSet(ctx))
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/test.Service/Watch")
+	require.NoError(t, err)
+
+	require.NoError(t, stream.SendMsg(&grpc_health_v1.HealthCheckRequest{}))
+	require.NoError(t, stream.CloseSend())
+
+	for {
+		if err := stream.RecvMsg(&grpc_health_v1.HealthCheckResponse{}); err != nil {
+			require.ErrorIs(t, err, io.EOF)
+
+			break
+		}
+	}
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 2)
+
+	serverSpan, clientSpan := ended[0], ended[1]
+
+	assert.Equal(t, trace.SpanKindClient, clientSpan.SpanKind())
+	assert.Equal(t, "GRPC /test.Service/Watch", clientSpan.Name())
+	assert.Contains(t, clientSpan.Attributes(), semconv.NetPeerNameKey.String("bufnet"))
+	assert.Contains(t, clientSpan.Attributes(), semconv.NetPeerPortKey.Int(50051))
+	assert.Contains(t, clientSpan.Attributes(), semconv.RPCGRPCStatusCodeKey.Int(0))
+	assert.Equal(t, clientSpan.SpanContext().SpanID(), serverSpan.Parent().SpanID())
+	assert.True(t, forcekeep.IsSet(ctx))
+}
+
+func TestStreamClientInterceptor_Canceled(t *testing.T) {
+	tracer, recorder := mocks.NewTracerWithRecorder()
+
+	conn := newStreamConn(t, tracer, func(_ interface{}, stream grpc.ServerStream) error {
+		<-stream.Context().Done()
+
+		return stream.Context().Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/test.Service/Watch")
+	require.NoError(t, err)
+
+	// The stream is abandoned without reading.
+	cancel()
+
+	canceled := semconv.RPCGRPCStatusCodeKey.Int(int(grpccodes.Canceled))
+
+	assert.Eventually(t, func() bool {
+		for _, span := range recorder.Ended() {
+			if span.SpanKind() != trace.SpanKindClient {
+				continue
+			}
+
+			for _, attr := range span.Attributes() {
+				if attr == canceled {
+					return true
+				}
+			}
+		}
+
+		return false
+	}, time.Second, 5*time.Millisecond)
+}
+
+// newStreamConn starts the server which handles all methods by the handler and returns
+// the traced client connection to it.
+func newStreamConn(t *testing.T, tracer trace.Tracer, handler grpc.StreamHandler) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+
+	server := grpc.NewServer(
+		grpc.ChainStreamInterceptor(StreamServerInterceptor(tracer)),
+		grpc.UnknownServiceHandler(handler),
+	)
+
+	go func() { _ = server.Serve(listener) }()
+
+	t.Cleanup(server.Stop)
+
+	conn, err := DialContext(context.Background(), "bufnet:50051", tracer,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+// newTestConn starts the health server with the tracing interceptor followed by the interceptor
+// and returns the traced client connection to it.
+func newTestConn(t *testing.T, tracer trace.Tracer, interceptor grpc.UnaryServerInterceptor) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(UnaryServerInterceptor(tracer), interceptor))
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+
+	go func() { _ = server.Serve(listener) }()
+
+	t.Cleanup(server.Stop)
+
+	conn, err := DialContext(context.Background(), "bufnet", tracer,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+// setPropagator enables the propagators for the test through the tracer configuration.
+func setPropagator(t *testing.T, names ...string) {
+	t.Helper()
+
+	newTracer := func(propagator propagation.TextMapPropagator) {
+		_, err := tracing.NewTracer(&tracing.Configuration{
+			ServiceName: "test",
+			Disabled:    true,
+			Sampler:     tracesdk.AlwaysSample(),
+			Propagator:  propagator,
+		})
+		require.NoError(t, err)
+	}
+
+	propagator, err := tracing.NewPropagator(names...)
+	require.NoError(t, err)
+
+	newTracer(propagator)
+
+	t.Cleanup(func() { newTracer(propagation.TraceContext{}) })
+}
+
+// enableForceKeep turns the force-keep handling on for the test through the tracer configuration.
+func enableForceKeep(t *testing.T) {
+	t.Helper()
+
+	newTracer := func(enabled bool) {
+		_, err := tracing.NewTracer(&tracing.Configuration{
+			ServiceName:  "test",
+			Disabled:     true,
+			Sampler:      tracesdk.AlwaysSample(),
+			TailSampling: tracing.TailSamplingConfiguration{ForceKeep: enabled},
+		})
+		require.NoError(t, err)
+	}
+
+	newTracer(true)
+
+	t.Cleanup(func() { newTracer(false) })
+}
@@ -3,11 +3,12 @@ package tracegrpc
 import (
 	"context"
 
-	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/loghole/tracing"
+	"github.com/loghole/tracing/internal/forcekeep"
 	"github.com/loghole/tracing/internal/metrics"
 )
 
@@ -24,12 +25,16 @@ func UnaryServerInterceptor(tracer trace.Tracer) grpc.UnaryServerInterceptor {
 			md = metadata.New(nil)
 		}
 
-		ctx = new(propagation.TraceContext).Extract(ctx, propagation.HeaderCarrier(md))
+		ctx = tracing.Extract(ctx, metadataCarrier(md))
 
-		ctx, span := tracer.Start(ctx, defaultNameFunc(info.FullMethod), trace.WithSpanKind(trace.SpanKindServer))
+		ctx, span := tracer.Start(forcekeep.WithFlag(ctx), defaultNameFunc(info.FullMethod),
+			trace.WithSpanKind(trace.SpanKindServer))
 		defer span.End()
 
 		resp, err = handler(ctx, req)
+
+		setForceKeep(ctx, func(md metadata.MD) { _ = grpc.SetTrailer(ctx, md) })
+
 		if err != nil {
 			metrics.GRPCFailedInputReqCounter.Inc()
 		} else {
@@ -49,14 +54,18 @@ func StreamServerInterceptor(tracer trace.Tracer) grpc.StreamServerInterceptor {
 			md = metadata.New(nil)
 		}
 
-		ctx := new(propagation.TraceContext).Extract(ss.Context(), propagation.HeaderCarrier(md))
+		ctx := tracing.Extract(ss.Context(), metadataCarrier(md))
 
-		ctx, span := tracer.Start(ctx, defaultNameFunc(info.FullMethod), trace.WithSpanKind(trace.SpanKindServer))
+		ctx, span := tracer.Start(forcekeep.WithFlag(ctx), defaultNameFunc(info.FullMethod),
+			trace.WithSpanKind(trace.SpanKindServer))
 		defer span.End()
 
 		ss = &tracingServerStream{ServerStream: ss, ctx: ctx}
 
 		err := handler(srv, ss)
+
+		setForceKeep(ctx, ss.SetTrailer)
+
 		if err != nil {
 			metrics.GRPCFailedInputReqCounter.Inc()
 		} else {
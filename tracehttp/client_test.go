@@ -11,7 +11,13 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 
+	"github.com/loghole/tracing"
+	"github.com/loghole/tracing/internal/forcekeep"
 	"github.com/loghole/tracing/mocks"
 )
 
@@ -33,6 +39,8 @@ func TestClient_Do(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.NotEmptyf(t, req.Header.Get("Traceparent"), "empty tracer header")
+	require.Len(t, recorder.Ended(), 1)
+	assert.Contains(t, recorder.Ended()[0].Attributes(), semconv.NetPeerNameKey.String("127.0.0.1"))
 
 	// error
 	req, err = http.NewRequestWithContext(ctx, "GET", "/", http.NoBody)
@@ -113,3 +121,151 @@ func TestClient_PostForm(t *testing.T) {
 	ended := recorder.Ended()
 	assert.Len(t, ended, 2)
 }
+
+func TestClient_ForceKeep(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{
+			name:    "enabled",
+			enabled: true,
+			want:    "1",
+		},
+		{
+			name:    "disabled",
+			enabled: false,
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setForceKeep(t, tt.enabled)
+
+			var (
+				tracer, recorder = mocks.NewTracerWithRecorder()
+				middleware       = NewMiddleware(tracer)
+			)
+
+			server := httptest.NewServer(middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/keep" {
+					tracing.ForceKeep(r.Context())
+				}
+
+				w.WriteHeader(http.StatusNotFound)
+			})))
+			defer server.Close()
+
+			client := NewClient(tracer, server.Client())
+
+			resp, err := client.Get(context.Background(), server.URL)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			assert.Empty(t, resp.Header.Get(forcekeep.Header), "failed requests are decided by the sampler")
+
+			resp, err = client.Get(context.Background(), server.URL+"/keep")
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			assert.Equal(t, tt.want, resp.Header.Get(forcekeep.Header))
+
+			ended := recorder.Ended()[2:]
+			require.Len(t, ended, 2)
+
+			for _, span := range ended {
+				assert.Equal(t, tt.enabled, hasAttribute(span.Attributes(), forcekeep.AttributeKey.Bool(true)), span.Name())
+			}
+
+			// The decision of the upstream service is echoed only if it is trusted.
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, http.NoBody)
+			require.NoError(t, err)
+
+			req.Header.Set("traceparent", "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01")
+			req.Header.Set("tracestate", "loghole=keep")
+
+			resp, err = server.Client().Do(req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			assert.Equal(t, tt.want, resp.Header.Get(forcekeep.Header))
+		})
+	}
+}
+
+func TestClient_Baggage(t *testing.T) {
+	setPropagator(t, "tracecontext", "baggage")
+
+	var (
+		tracer, _  = mocks.NewTracerWithRecorder()
+		middleware = NewMiddleware(tracer)
+		tenant     string
+	)
+
+	server := httptest.NewServer(middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = tracing.BaggageValue(r.Context(), "tenant.id")
+	})))
+	defer server.Close()
+
+	ctx, err := tracing.WithBaggage(context.Background(), "tenant.id", "42")
+	require.NoError(t, err)
+
+	resp, err := NewClient(tracer, server.Client()).Get(ctx, server.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, "42", tenant)
+}
+
+// setPropagator enables the propagators for the test through the tracer configuration.
+func setPropagator(t *testing.T, names ...string) {
+	t.Helper()
+
+	newTracer := func(propagator propagation.TextMapPropagator) {
+		_, err := tracing.NewTracer(&tracing.Configuration{
+			ServiceName: "test",
+			Disabled:    true,
+			Sampler:     tracesdk.AlwaysSample(),
+			Propagator:  propagator,
+		})
+		require.NoError(t, err)
+	}
+
+	propagator, err := tracing.NewPropagator(names...)
+	require.NoError(t, err)
+
+	newTracer(propagator)
+
+	t.Cleanup(func() { newTracer(propagation.TraceContext{}) })
+}
+
+// setForceKeep turns the force-keep handling on or off for the test through the tracer configuration.
+func setForceKeep(t *testing.T, enabled bool) {
+	t.Helper()
+
+	newTracer := func(enabled bool) {
+		_, err := tracing.NewTracer(&tracing.Configuration{
+			ServiceName:  "test",
+			Disabled:     true,
+			Sampler:      tracesdk.AlwaysSample(),
+			TailSampling: tracing.TailSamplingConfiguration{ForceKeep: enabled},
+		})
+		require.NoError(t, err)
+	}
+
+	newTracer(enabled)
+
+	t.Cleanup(func() { newTracer(false) })
+}
+
+func hasAttribute(attributes []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attributes {
+		if attr == want {
+			return true
+		}
+	}
+
+	return false
+}
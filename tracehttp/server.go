@@ -8,6 +8,8 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/loghole/tracing"
+	"github.com/loghole/tracing/internal/forcekeep"
 	"github.com/loghole/tracing/internal/metrics"
 )
 
@@ -69,12 +71,17 @@ func (m *Middleware) Middleware(next http.Handler) http.Handler {
 
 		var (
 			tracker = NewStatusCodeTracker(w)
-			ctx     = new(propagation.TraceContext).Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx     = tracing.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
 		)
 
-		ctx, span := m.tracer.Start(ctx, defaultNameFunc(r), trace.WithSpanKind(trace.SpanKindServer))
+		ctx, span := m.tracer.Start(forcekeep.WithFlag(ctx), defaultNameFunc(r), trace.WithSpanKind(trace.SpanKindServer))
 		defer span.End()
 
+		// Ask the upstream service to keep the trace if this service keeps it.
+		tracker.beforeWriteHeader = func() {
+			forcekeep.SetHeader(ctx, w.Header())
+		}
+
 		next.ServeHTTP(tracker.Writer(), r.WithContext(ctx))
 
 		span.SetAttributes(
@@ -7,7 +7,11 @@ import (
 
 type StatusCodeTracker struct {
 	http.ResponseWriter
-	status int
+	status      int
+	wroteHeader bool
+
+	// beforeWriteHeader is called before the response header is written.
+	beforeWriteHeader func()
 }
 
 func NewStatusCodeTracker(w http.ResponseWriter) *StatusCodeTracker {
@@ -18,10 +22,23 @@ func NewStatusCodeTracker(w http.ResponseWriter) *StatusCodeTracker {
 }
 
 func (w *StatusCodeTracker) WriteHeader(status int) {
+	if !w.wroteHeader && w.beforeWriteHeader != nil {
+		w.beforeWriteHeader()
+	}
+
 	w.status = status
+	w.wroteHeader = true
 	w.ResponseWriter.WriteHeader(status)
 }
 
+func (w *StatusCodeTracker) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
 // Writer returns a wrapped version of the original
 // ResponseWriter and only implements the same combination of additional
 // interfaces as the original. This implementation is based on
@@ -2,12 +2,16 @@ package tracehttp
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/loghole/tracing"
+	"github.com/loghole/tracing/internal/forcekeep"
 )
 
 type Transport struct {
@@ -40,6 +44,8 @@ func (t *Transport) RoundTrip(req *http.Request) (resp *http.Response, err error
 		semconv.HTTPRequestContentLengthKey.Int64(req.ContentLength),
 	)
 
+	span.SetAttributes(peerAttributes(req.URL)...)
+
 	tracing.InjectHeaders(ctx, req.Header)
 
 	resp, err = t.base.RoundTrip(req.WithContext(ctx))
@@ -52,9 +58,26 @@ func (t *Transport) RoundTrip(req *http.Request) (resp *http.Response, err error
 
 	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
 
+	forcekeep.FromHeader(ctx, resp.Header)
+
 	return resp, nil
 }
 
+// peerAttributes returns remote endpoint attributes, they are used by exporters like zipkin.
+func peerAttributes(u *url.URL) []attribute.KeyValue {
+	if u == nil || u.Hostname() == "" {
+		return nil
+	}
+
+	attributes := []attribute.KeyValue{semconv.NetPeerNameKey.String(u.Hostname())}
+
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		attributes = append(attributes, semconv.NetPeerPortKey.Int(port))
+	}
+
+	return attributes
+}
+
 func defaultNameFunc(req *http.Request) string {
 	return "HTTP " + req.Method + " " + req.RequestURI
 }
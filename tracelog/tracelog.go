@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -33,12 +34,29 @@ type Logger interface {
 
 type TraceLogger struct {
 	*zap.SugaredLogger
+
+	baggageKeys []string
 }
 
-func NewTraceLogger(logger *zap.SugaredLogger) *TraceLogger {
-	return &TraceLogger{
+type Option func(l *TraceLogger)
+
+// WithBaggageKeys adds values of the baggage members to log lines.
+func WithBaggageKeys(keys ...string) Option {
+	return func(l *TraceLogger) {
+		l.baggageKeys = append(l.baggageKeys, keys...)
+	}
+}
+
+func NewTraceLogger(logger *zap.SugaredLogger, options ...Option) *TraceLogger {
+	l := &TraceLogger{
 		SugaredLogger: logger.Desugar().WithOptions(zap.AddCallerSkip(1), zap.Hooks(metricHook)).Sugar(),
 	}
+
+	for _, option := range options {
+		option(l)
+	}
+
+	return l
 }
 
 func (l *TraceLogger) Debug(ctx context.Context, args ...interface{}) {
@@ -96,14 +114,27 @@ func (l *TraceLogger) TraceID(ctx context.Context) string {
 }
 
 func (l *TraceLogger) withSpanContext(ctx context.Context) *zap.SugaredLogger {
+	var fields []zap.Field
+
 	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
-		return l.SugaredLogger.Desugar().With(
-			zap.Stringer(traceKey, sc.TraceID()),
-			zap.Stringer(spanKey, sc.SpanID()),
-		).Sugar()
+		fields = append(fields, zap.Stringer(traceKey, sc.TraceID()), zap.Stringer(spanKey, sc.SpanID()))
+	}
+
+	if len(l.baggageKeys) > 0 {
+		bag := baggage.FromContext(ctx)
+
+		for _, key := range l.baggageKeys {
+			if member := bag.Member(key); member.Key() != "" {
+				fields = append(fields, zap.String(key, member.Value()))
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		return l.SugaredLogger
 	}
 
-	return l.SugaredLogger
+	return l.SugaredLogger.Desugar().With(fields...).Sugar()
 }
 
 func TraceID(ctx context.Context) string {
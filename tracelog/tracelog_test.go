@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
 	"go.uber.org/zap"
 
 	"github.com/loghole/tracing/mocks"
@@ -511,3 +513,22 @@ func TestTraceLogger_WithJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestTraceLogger_WithBaggageKeys(t *testing.T) {
+	var (
+		logger = mocks.NewMockLogger()
+		l      = NewTraceLogger(logger.SugaredLogger, WithBaggageKeys("tenant.id", "missing"))
+	)
+
+	member, err := baggage.NewMember("tenant.id", "42")
+	require.NoError(t, err)
+
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+
+	l.Info(baggage.ContextWithBaggage(context.Background(), bag), "message")
+
+	require.NoError(t, logger.Sync())
+
+	assert.Equal(t, "info\tmessage\t{\"tenant.id\": \"42\"}\n", logger.String())
+}
@@ -0,0 +1,147 @@
+package tracemsg
+
+import (
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var (
+	_ propagation.TextMapCarrier = new(HeaderSlice[KafkaHeader])
+	_ propagation.TextMapCarrier = AMQPTable(nil)
+	_ propagation.TextMapCarrier = NATSHeader(nil)
+)
+
+// KafkaHeader has the same fields as headers of segmentio/kafka-go and confluent-kafka-go.
+type KafkaHeader struct {
+	Key   string
+	Value []byte
+}
+
+// HeaderSlice adapts the slice of message headers to the TextMapCarrier, Set replaces
+// the existing header or appends the new one to the slice.
+type HeaderSlice[H any] struct {
+	headers *[]H
+
+	key       func(header H) string
+	value     func(header H) []byte
+	newHeader func(key string, value []byte) H
+}
+
+// NewHeaderSlice returns the carrier for headers of any kafka client, for example sarama:
+//
+//	carrier := tracemsg.NewHeaderSlice(&msg.Headers,
+//		func(h sarama.RecordHeader) string { return string(h.Key) },
+//		func(h sarama.RecordHeader) []byte { return h.Value },
+//		func(key string, value []byte) sarama.RecordHeader {
+//			return sarama.RecordHeader{Key: []byte(key), Value: value}
+//		},
+//	)
+func NewHeaderSlice[H any](
+	headers *[]H,
+	key func(header H) string,
+	value func(header H) []byte,
+	newHeader func(key string, value []byte) H,
+) *HeaderSlice[H] {
+	return &HeaderSlice[H]{headers: headers, key: key, value: value, newHeader: newHeader}
+}
+
+// KafkaHeaders returns the carrier for the KafkaHeader slice.
+func KafkaHeaders(headers *[]KafkaHeader) *HeaderSlice[KafkaHeader] {
+	return NewHeaderSlice(headers,
+		func(header KafkaHeader) string { return header.Key },
+		func(header KafkaHeader) []byte { return header.Value },
+		func(key string, value []byte) KafkaHeader { return KafkaHeader{Key: key, Value: value} },
+	)
+}
+
+func (c *HeaderSlice[H]) Get(key string) string {
+	for _, header := range *c.headers {
+		if c.key(header) == key {
+			return string(c.value(header))
+		}
+	}
+
+	return ""
+}
+
+func (c *HeaderSlice[H]) Set(key, value string) {
+	for idx, header := range *c.headers {
+		if c.key(header) == key {
+			(*c.headers)[idx] = c.newHeader(key, []byte(value))
+
+			return
+		}
+	}
+
+	*c.headers = append(*c.headers, c.newHeader(key, []byte(value)))
+}
+
+func (c *HeaderSlice[H]) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+
+	for _, header := range *c.headers {
+		keys = append(keys, c.key(header))
+	}
+
+	return keys
+}
+
+// AMQPTable adapts amqp.Table headers of rabbitmq clients to the TextMapCarrier:
+//
+//	tracemsg.AMQPTable(delivery.Headers)
+//
+// The table must be initialized before Set.
+type AMQPTable map[string]interface{}
+
+func (c AMQPTable) Get(key string) string {
+	switch value := c[key].(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	default:
+		return ""
+	}
+}
+
+func (c AMQPTable) Set(key, value string) {
+	c[key] = value
+}
+
+func (c AMQPTable) Keys() []string {
+	keys := make([]string, 0, len(c))
+
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// NATSHeader adapts nats.Header to the TextMapCarrier, keys are case-sensitive:
+//
+//	tracemsg.NATSHeader(msg.Header)
+//
+// The header must be initialized before Set.
+type NATSHeader map[string][]string
+
+func (c NATSHeader) Get(key string) string {
+	if values := c[key]; len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func (c NATSHeader) Set(key, value string) {
+	c[key] = []string{value}
+}
+
+func (c NATSHeader) Keys() []string {
+	keys := make([]string, 0, len(c))
+
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
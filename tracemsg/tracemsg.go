@@ -0,0 +1,140 @@
+// Package tracemsg traces message producers and consumers, the trace context is sent
+// in message headers with the tracing.Propagator.
+package tracemsg
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/loghole/tracing"
+)
+
+// Messaging systems.
+const (
+	SystemKafka    = "kafka"
+	SystemRabbitMQ = "rabbitmq"
+	SystemNATS     = "nats"
+)
+
+const (
+	_operationSend    = "send"
+	_operationProcess = "process"
+)
+
+// Message describes the produced or consumed message.
+type Message struct {
+	// System is the messaging system, e.g. SystemKafka.
+	System string
+	// Destination is the topic, queue or subject name.
+	Destination string
+	// Queue marks the destination as a queue, it is a topic by default.
+	Queue bool
+	// ID is the message id, optional.
+	ID string
+	// PayloadSize is the size of the message body in bytes, optional.
+	PayloadSize int
+	// Headers carry the trace context.
+	Headers propagation.TextMapCarrier
+	// Attributes are added to the span, e.g. semconv.MessagingKafkaPartitionKey.
+	Attributes []attribute.KeyValue
+}
+
+// Batch describes the batch of consumed messages.
+type Batch struct {
+	System      string
+	Destination string
+	Queue       bool
+	// Headers of the messages, the span is linked to trace contexts of all messages.
+	Headers    []propagation.TextMapCarrier
+	Attributes []attribute.KeyValue
+}
+
+// StartProducer starts the producer span and injects its context into the message headers.
+func StartProducer(ctx context.Context, tracer trace.Tracer, msg Message) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, spanName(msg.Destination, _operationSend),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(msg.attributes()...),
+	)
+
+	if msg.Headers != nil {
+		tracing.Inject(ctx, msg.Headers)
+	}
+
+	return ctx, span
+}
+
+// StartConsumer starts the consumer span which is a child of the producer span from the message headers.
+func StartConsumer(ctx context.Context, tracer trace.Tracer, msg Message) (context.Context, trace.Span) {
+	if msg.Headers != nil {
+		ctx = tracing.Extract(ctx, msg.Headers)
+	}
+
+	return tracer.Start(ctx, spanName(msg.Destination, _operationProcess),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(msg.attributes()...),
+		trace.WithAttributes(semconv.MessagingOperationProcess),
+	)
+}
+
+// StartBatchConsumer starts the consumer span of the batch, it is linked to producer spans
+// of the messages instead of being their child, because the batch belongs to many traces.
+func StartBatchConsumer(ctx context.Context, tracer trace.Tracer, batch Batch) (context.Context, trace.Span) {
+	links := make([]trace.Link, 0, len(batch.Headers))
+
+	for _, headers := range batch.Headers {
+		// Extract into the empty context, so the span of ctx is not linked if headers are empty.
+		if spanCtx := trace.SpanContextFromContext(tracing.Extract(context.Background(), headers)); spanCtx.IsValid() {
+			links = append(links, trace.Link{SpanContext: spanCtx})
+		}
+	}
+
+	attributes := append(commonAttributes(batch.System, batch.Destination, batch.Queue),
+		semconv.MessagingOperationProcess,
+		attribute.Int("messaging.batch.message_count", len(batch.Headers)),
+	)
+
+	return tracer.Start(ctx, spanName(batch.Destination, _operationProcess),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(append(attributes, batch.Attributes...)...),
+		trace.WithLinks(links...),
+	)
+}
+
+func (m *Message) attributes() []attribute.KeyValue {
+	attributes := commonAttributes(m.System, m.Destination, m.Queue)
+
+	if m.ID != "" {
+		attributes = append(attributes, semconv.MessagingMessageIDKey.String(m.ID))
+	}
+
+	if m.PayloadSize > 0 {
+		attributes = append(attributes, semconv.MessagingMessagePayloadSizeBytesKey.Int(m.PayloadSize))
+	}
+
+	return append(attributes, m.Attributes...)
+}
+
+func commonAttributes(system, destination string, queue bool) []attribute.KeyValue {
+	kind := semconv.MessagingDestinationKindTopic
+	if queue {
+		kind = semconv.MessagingDestinationKindQueue
+	}
+
+	return []attribute.KeyValue{
+		semconv.MessagingSystemKey.String(system),
+		semconv.MessagingDestinationKey.String(destination),
+		kind,
+	}
+}
+
+func spanName(destination, operation string) string {
+	if destination == "" {
+		return operation
+	}
+
+	return destination + " " + operation
+}
@@ -0,0 +1,213 @@
+package tracemsg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/loghole/tracing/mocks"
+)
+
+// fakeBroker keeps messages in memory, headers are created by the client specific carrier.
+type fakeBroker struct {
+	name    string
+	system  string
+	queue   bool
+	headers func() propagation.TextMapCarrier
+
+	messages map[string][]propagation.TextMapCarrier
+}
+
+func (b *fakeBroker) publish(ctx context.Context, tracer trace.Tracer, destination string) {
+	headers := b.headers()
+
+	_, span := StartProducer(ctx, tracer, Message{
+		System:      b.system,
+		Destination: destination,
+		Queue:       b.queue,
+		PayloadSize: 4,
+		Headers:     headers,
+	})
+	defer span.End()
+
+	b.messages[destination] = append(b.messages[destination], headers)
+}
+
+func (b *fakeBroker) receive(destination string) []propagation.TextMapCarrier {
+	messages := b.messages[destination]
+	delete(b.messages, destination)
+
+	return messages
+}
+
+// saramaHeader has the shape of sarama.RecordHeader.
+type saramaHeader struct {
+	Key   []byte
+	Value []byte
+}
+
+func newBrokers() []*fakeBroker {
+	return []*fakeBroker{
+		{
+			name:   "kafka-go",
+			system: SystemKafka,
+			headers: func() propagation.TextMapCarrier {
+				return KafkaHeaders(&[]KafkaHeader{})
+			},
+		},
+		{
+			name:   "sarama",
+			system: SystemKafka,
+			headers: func() propagation.TextMapCarrier {
+				return NewHeaderSlice(&[]saramaHeader{},
+					func(h saramaHeader) string { return string(h.Key) },
+					func(h saramaHeader) []byte { return h.Value },
+					func(key string, value []byte) saramaHeader { return saramaHeader{Key: []byte(key), Value: value} },
+				)
+			},
+		},
+		{
+			name:    "amqp",
+			system:  SystemRabbitMQ,
+			queue:   true,
+			headers: func() propagation.TextMapCarrier { return AMQPTable{} },
+		},
+		{
+			name:    "nats",
+			system:  SystemNATS,
+			headers: func() propagation.TextMapCarrier { return NATSHeader{} },
+		},
+	}
+}
+
+func TestProducerConsumer(t *testing.T) {
+	for _, broker := range newBrokers() {
+		broker.messages = make(map[string][]propagation.TextMapCarrier)
+
+		t.Run(broker.name, func(t *testing.T) {
+			tracer, recorder := mocks.NewTracerWithRecorder()
+
+			broker.publish(context.Background(), tracer, "orders")
+
+			messages := broker.receive("orders")
+			require.Len(t, messages, 1)
+
+			_, span := StartConsumer(context.Background(), tracer, Message{
+				System:      broker.system,
+				Destination: "orders",
+				Queue:       broker.queue,
+				ID:          "1",
+				Headers:     messages[0],
+			})
+			span.End()
+
+			ended := recorder.Ended()
+			require.Len(t, ended, 2)
+
+			producer, consumer := ended[0], ended[1]
+
+			assert.Equal(t, "orders send", producer.Name())
+			assert.Equal(t, trace.SpanKindProducer, producer.SpanKind())
+			assert.Contains(t, producer.Attributes(), semconv.MessagingSystemKey.String(broker.system))
+			assert.Contains(t, producer.Attributes(), semconv.MessagingDestinationKey.String("orders"))
+			assert.Contains(t, producer.Attributes(), semconv.MessagingMessagePayloadSizeBytesKey.Int(4))
+
+			assert.Equal(t, "orders process", consumer.Name())
+			assert.Equal(t, trace.SpanKindConsumer, consumer.SpanKind())
+			assert.Contains(t, consumer.Attributes(), semconv.MessagingOperationProcess)
+			assert.Contains(t, consumer.Attributes(), semconv.MessagingMessageIDKey.String("1"))
+			assert.Equal(t, producer.SpanContext().TraceID(), consumer.SpanContext().TraceID())
+			assert.Equal(t, producer.SpanContext().SpanID(), consumer.Parent().SpanID())
+			assert.True(t, consumer.Parent().IsRemote())
+
+			if broker.queue {
+				assert.Contains(t, consumer.Attributes(), semconv.MessagingDestinationKindQueue)
+			} else {
+				assert.Contains(t, consumer.Attributes(), semconv.MessagingDestinationKindTopic)
+			}
+		})
+	}
+}
+
+func TestStartBatchConsumer(t *testing.T) {
+	var (
+		tracer, recorder = mocks.NewTracerWithRecorder()
+		broker           = newBrokers()[0]
+	)
+
+	broker.messages = make(map[string][]propagation.TextMapCarrier)
+
+	for i := 0; i < 3; i++ {
+		broker.publish(context.Background(), tracer, "orders")
+	}
+
+	messages := broker.receive("orders")
+
+	ctx, poll := tracer.Start(context.Background(), "poll")
+
+	_, span := StartBatchConsumer(ctx, tracer, Batch{
+		System:      SystemKafka,
+		Destination: "orders",
+		Headers:     append(messages, KafkaHeaders(&[]KafkaHeader{})),
+	})
+	span.End()
+	poll.End()
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 5)
+
+	batch := ended[3]
+
+	assert.Equal(t, "orders process", batch.Name())
+	assert.Equal(t, poll.SpanContext().SpanID(), batch.Parent().SpanID(), "batch is not a child of producers")
+	require.Len(t, batch.Links(), 3, "message without the trace context is not linked")
+
+	for idx, link := range batch.Links() {
+		assert.Equal(t, ended[idx].SpanContext().TraceID(), link.SpanContext.TraceID())
+		assert.Equal(t, ended[idx].SpanContext().SpanID(), link.SpanContext.SpanID())
+	}
+
+	assert.Contains(t, batch.Attributes(), semconv.MessagingOperationProcess)
+}
+
+func TestHeaderSlice(t *testing.T) {
+	headers := []KafkaHeader{{Key: "key", Value: []byte("value")}}
+
+	carrier := KafkaHeaders(&headers)
+
+	carrier.Set("traceparent", "first")
+	carrier.Set("traceparent", "second")
+
+	assert.Equal(t, "value", carrier.Get("key"))
+	assert.Equal(t, "second", carrier.Get("traceparent"))
+	assert.Empty(t, carrier.Get("missing"))
+	assert.Equal(t, []string{"key", "traceparent"}, carrier.Keys())
+	assert.Len(t, headers, 2)
+}
+
+func TestAMQPTable(t *testing.T) {
+	carrier := AMQPTable{"bytes": []byte("value"), "number": int32(1)}
+
+	carrier.Set("traceparent", "value")
+
+	assert.Equal(t, "value", carrier.Get("bytes"))
+	assert.Equal(t, "value", carrier.Get("traceparent"))
+	assert.Empty(t, carrier.Get("number"))
+	assert.ElementsMatch(t, []string{"bytes", "number", "traceparent"}, carrier.Keys())
+}
+
+func TestNATSHeader(t *testing.T) {
+	carrier := NATSHeader{"Key": []string{"first", "second"}}
+
+	carrier.Set("traceparent", "value")
+
+	assert.Equal(t, "first", carrier.Get("Key"))
+	assert.Empty(t, carrier.Get("key"), "keys are case-sensitive")
+	assert.Equal(t, "value", carrier.Get("traceparent"))
+	assert.ElementsMatch(t, []string{"Key", "traceparent"}, carrier.Keys())
+}
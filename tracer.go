@@ -2,20 +2,18 @@ package tracing
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"time"
 
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/loghole/tracing/internal/forcekeep"
 	"github.com/loghole/tracing/internal/logtracer"
-	"github.com/loghole/tracing/internal/spanprocessor"
+	"github.com/loghole/tracing/spanprocessor"
 )
 
 const _defaultTracerName = "github.com/loghole/tracing"
@@ -30,7 +28,18 @@ type Tracer struct {
 	shutdown func(ctx context.Context) error
 }
 
-// NewTracer returns initialized Tracer with jaeger exporter.
+// NewTracer returns initialized Tracer with exporter selected by the configuration.
+//
+// The exporter is detected from the Addr scheme:
+//   - http, https, udp, jaeger: jaeger exporter, jaeger is the collector endpoint over http;
+//   - otlp+grpc: OTLP gRPC exporter;
+//   - otlp+http: OTLP HTTP exporter;
+//   - zipkin, zipkin+https: zipkin exporter;
+//   - file, stdout: line-delimited JSON or tree per trace, useful for local development;
+//   - custom schemes added by RegisterExporter.
+//
+// Additional exporters from Configuration.Exporters receive the same sampled spans,
+// each of them has own batch span processor.
 //
 // Example:
 //
@@ -55,6 +64,13 @@ func NewTracer(configuration *Configuration) (*Tracer, error) {
 		return nil, err
 	}
 
+	if configuration.Propagator != nil {
+		setPropagator(configuration.Propagator)
+		otel.SetTextMapPropagator(configuration.Propagator)
+	}
+
+	forcekeep.SetEnabled(configuration.TailSampling.ForceKeep)
+
 	if configuration.Disabled {
 		var (
 			provider = logtracer.NewProvider()
@@ -66,28 +82,28 @@ func NewTracer(configuration *Configuration) (*Tracer, error) {
 		return &Tracer{provider: provider, tracer: tracer}, nil
 	}
 
-	endpoint, err := configuration.endpoint()
+	ctx := context.Background()
+
+	res, err := configuration.resource(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	exporter, err := jaeger.New(endpoint)
+	exporters, err := configuration.processor(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("init jaeger exporter: %w", err)
+		return nil, err
 	}
 
-	processor := spanprocessor.NewSampled(
-		tracesdk.NewBatchSpanProcessor(exporter, configuration.SpanProcessorOptions...),
-		configuration.Sampler,
-	)
+	processor := spanprocessor.NewSampled(exporters, configuration.Sampler, configuration.TailSampling.options()...)
 
-	provider := tracesdk.NewTracerProvider(
-		tracesdk.WithSpanProcessor(processor),
-		tracesdk.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			append(configuration.Attributes, semconv.ServiceNameKey.String(configuration.ServiceName))...,
-		)),
-	)
+	options := []tracesdk.TracerProviderOption{tracesdk.WithResource(res)}
+
+	// Baggage attributes must be set before the sampler reads them.
+	if len(configuration.BaggageAttributes) > 0 {
+		options = append(options, tracesdk.WithSpanProcessor(spanprocessor.NewBaggage(configuration.BaggageAttributes...)))
+	}
+
+	provider := tracesdk.NewTracerProvider(append(options, tracesdk.WithSpanProcessor(processor))...)
 
 	otel.SetTracerProvider(provider)
 
@@ -163,7 +179,54 @@ func (b SpanBuilder) WithName(name string) SpanBuilder {
 	return b
 }
 
-// ExtractMap reads tracecontext from the `map[string]string` carrier and set remote SpanContext for new span.
+// WithKind sets the span kind, internal by default.
+func (b SpanBuilder) WithKind(kind trace.SpanKind) SpanBuilder {
+	return b.with(trace.WithSpanKind(kind))
+}
+
+// WithAttributes adds attributes to the span on start, so samplers can use them.
+func (b SpanBuilder) WithAttributes(attributes ...attribute.KeyValue) SpanBuilder {
+	return b.with(trace.WithAttributes(attributes...))
+}
+
+// WithTag adds the attribute to the span on start, the value is converted like in Span.SetTag.
+func (b SpanBuilder) WithTag(key string, value interface{}) SpanBuilder {
+	return b.with(trace.WithAttributes(attributeFromInterface(key, value)))
+}
+
+// WithLinks links the span to other spans.
+func (b SpanBuilder) WithLinks(links ...trace.Link) SpanBuilder {
+	return b.with(trace.WithLinks(links...))
+}
+
+// WithLinkFromContext links the span to the span of the context, nothing is linked if ctx has no span.
+func (b SpanBuilder) WithLinkFromContext(ctx context.Context, attributes ...attribute.KeyValue) SpanBuilder {
+	link := trace.LinkFromContext(ctx, attributes...)
+	if !link.SpanContext.IsValid() {
+		return b
+	}
+
+	return b.with(trace.WithLinks(link))
+}
+
+// WithTimestamp sets the span start time.
+func (b SpanBuilder) WithTimestamp(timestamp time.Time) SpanBuilder {
+	return b.with(trace.WithTimestamp(timestamp))
+}
+
+// WithNewRoot makes the span a root span even if the context or the carrier contains a span.
+func (b SpanBuilder) WithNewRoot() SpanBuilder {
+	return b.with(trace.WithNewRoot())
+}
+
+// with returns the builder with the options, builders created from one parent do not share options.
+func (b SpanBuilder) with(options ...trace.SpanStartOption) SpanBuilder {
+	b.options = append(b.options[:len(b.options):len(b.options)], options...)
+
+	return b
+}
+
+// ExtractMap reads the trace context from the `map[string]string` carrier and set remote SpanContext for new span.
 func (b SpanBuilder) ExtractMap(carrier map[string]string) SpanBuilder {
 	if carrier == nil {
 		return b
@@ -174,7 +237,7 @@ func (b SpanBuilder) ExtractMap(carrier map[string]string) SpanBuilder {
 	return b
 }
 
-// ExtractHeaders reads tracecontext from the `http.Header` carrier and set remote SpanContext for new span.
+// ExtractHeaders reads the trace context from the `http.Header` carrier and set remote SpanContext for new span.
 func (b SpanBuilder) ExtractHeaders(carrier http.Header) SpanBuilder {
 	if carrier == nil {
 		return b
@@ -185,6 +248,17 @@ func (b SpanBuilder) ExtractHeaders(carrier http.Header) SpanBuilder {
 	return b
 }
 
+// Extract reads the trace context from any carrier, e.g. message headers, and set remote SpanContext for new span.
+func (b SpanBuilder) Extract(carrier propagation.TextMapCarrier) SpanBuilder {
+	if carrier == nil {
+		return b
+	}
+
+	b.carrier = carrier
+
+	return b
+}
+
 // Start creates a span.
 func (b SpanBuilder) Start(ctx context.Context) *Span {
 	_, span := b.StartWithContext(ctx)
@@ -199,7 +273,7 @@ func (b SpanBuilder) StartWithContext(ctx context.Context) (context.Context, *Sp
 	}
 
 	if b.carrier != nil {
-		ctx = new(propagation.TraceContext).Extract(ctx, b.carrier)
+		ctx = Extract(ctx, b.carrier)
 	}
 
 	ctx, span := b.tracer.Start(ctx, b.name, b.options...)
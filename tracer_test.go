@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// parametersSampler keeps parameters of the last sampling decision.
+type parametersSampler struct {
+	parameters tracesdk.SamplingParameters
+}
+
+func (s *parametersSampler) ShouldSample(parameters tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	s.parameters = parameters
+
+	return tracesdk.AlwaysSample().ShouldSample(parameters)
+}
+
+func (s *parametersSampler) Description() string {
+	return "parameters"
+}
+
+func TestSpanBuilder_Options(t *testing.T) {
+	var (
+		sampler  = &parametersSampler{}
+		recorder = tracetest.NewSpanRecorder()
+		provider = tracesdk.NewTracerProvider(tracesdk.WithSampler(sampler), tracesdk.WithSpanProcessor(recorder))
+		tracer   = &Tracer{provider: provider, tracer: provider.Tracer("")}
+		start    = time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	)
+
+	linkCtx, linked := tracer.Start(context.Background(), "linked")
+	linked.End()
+
+	parentCtx, parent := tracer.Start(context.Background(), "parent")
+	defer parent.End()
+
+	span := tracer.NewSpan().
+		WithName("builder").
+		WithKind(trace.SpanKindServer).
+		WithAttributes(attribute.String("http.route", "/users")).
+		WithTag("retries", 3).
+		WithLinkFromContext(linkCtx, attribute.String("reason", "retry")).
+		WithLinkFromContext(context.Background()).
+		WithTimestamp(start).
+		WithNewRoot().
+		Start(parentCtx)
+	span.End()
+
+	attributes := []attribute.KeyValue{attribute.String("http.route", "/users"), attribute.Int("retries", 3)}
+
+	assert.Equal(t, trace.SpanKindServer, sampler.parameters.Kind)
+	assert.Equal(t, attributes, sampler.parameters.Attributes, "attributes are visible to the sampler")
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 2)
+
+	got := ended[1]
+
+	assert.Equal(t, "builder", got.Name())
+	assert.Equal(t, trace.SpanKindServer, got.SpanKind())
+	assert.Equal(t, attributes, got.Attributes())
+	assert.Equal(t, start, got.StartTime())
+	assert.False(t, got.Parent().IsValid(), "new root")
+	assert.NotEqual(t, parent.SpanContext().TraceID(), got.SpanContext().TraceID())
+
+	require.Len(t, got.Links(), 1)
+	assert.Equal(t, linked.SpanContext(), got.Links()[0].SpanContext)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("reason", "retry")}, got.Links()[0].Attributes)
+}
+
+func TestSpanBuilder_WithLinks(t *testing.T) {
+	var (
+		recorder = tracetest.NewSpanRecorder()
+		provider = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+		tracer   = &Tracer{provider: provider, tracer: provider.Tracer("")}
+	)
+
+	link := trace.Link{SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{1},
+	})}
+
+	tracer.NewSpan().WithName("span").WithLinks(link).Start(context.Background()).End()
+
+	require.Len(t, recorder.Ended(), 1)
+	require.Len(t, recorder.Ended()[0].Links(), 1)
+	assert.Equal(t, link.SpanContext, recorder.Ended()[0].Links()[0].SpanContext)
+}
+
+func TestSpanBuilder_Copy(t *testing.T) {
+	var (
+		recorder = tracetest.NewSpanRecorder()
+		provider = tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+		tracer   = &Tracer{provider: provider, tracer: provider.Tracer("")}
+	)
+
+	// Three options leave spare capacity in the options slice.
+	base := tracer.NewSpan().WithName("span").WithTag("a", 1).WithTag("b", 2).WithTag("c", 3)
+
+	first := base.WithTag("d", "first")
+	second := base.WithTag("d", "second")
+
+	first.Start(context.Background()).End()
+	second.Start(context.Background()).End()
+
+	require.Len(t, recorder.Ended(), 2)
+	assert.Contains(t, recorder.Ended()[0].Attributes(), attribute.String("d", "first"))
+	assert.Contains(t, recorder.Ended()[1].Attributes(), attribute.String("d", "second"))
+}